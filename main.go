@@ -15,13 +15,14 @@ var (
 
 func main() {
 	fs := afero.NewOsFs()
-	clipboard := NewSystemClipboard()
+	clipboard := NewClipboardManager(os.Getenv)
 	runCfg := RunConfig{
 		Version:   version,
 		Commit:    commit,
 		Date:      date,
 		Fs:        fs,
 		Clipboard: clipboard,
+		Stdin:     os.Stdin,
 	}
 
 	if err := Run(os.Args[1:], runCfg); err != nil {
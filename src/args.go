@@ -1,6 +1,7 @@
 package src
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -9,6 +10,8 @@ import (
 
 var OsStat = os.Stat
 
+const DefaultMaxFileSize = 500 * 1024
+
 // ErrInvalidMaxFileSize is a custom error type for invalid max filesize arguments
 type ErrInvalidMaxFileSize struct {
 	Value string
@@ -18,6 +21,121 @@ func (e ErrInvalidMaxFileSize) Error() string {
 	return fmt.Sprintf("invalid max filesize: %s", e.Value)
 }
 
+// ErrMissingGitRef is returned when --git-since or --diff-base is not followed by a ref
+type ErrMissingGitRef struct{}
+
+func (e ErrMissingGitRef) Error() string {
+	return "missing git ref"
+}
+
+// ErrMissingOutputFile is returned when --output or --report-file is not followed by a path
+type ErrMissingOutputFile struct{}
+
+func (e ErrMissingOutputFile) Error() string {
+	return "missing output file"
+}
+
+// ErrInvalidSplitSize is a custom error type for invalid part sizes
+type ErrInvalidSplitSize struct {
+	Value string
+}
+
+func (e ErrInvalidSplitSize) Error() string {
+	return fmt.Sprintf("invalid split size: %s", e.Value)
+}
+
+// ErrInvalidMaxTokens is a custom error type for invalid token budgets
+type ErrInvalidMaxTokens struct {
+	Value string
+}
+
+func (e ErrInvalidMaxTokens) Error() string {
+	return fmt.Sprintf("invalid max tokens: %s", e.Value)
+}
+
+// ErrMissingProfile is returned when --profile is not followed by a name
+type ErrMissingProfile struct{}
+
+func (e ErrMissingProfile) Error() string {
+	return "missing profile name"
+}
+
+// ErrMissingTemplate is returned when --template is not followed by a name
+type ErrMissingTemplate struct{}
+
+func (e ErrMissingTemplate) Error() string {
+	return "missing template name"
+}
+
+// ErrMissingQuestion is returned when --ask is not followed by a question
+type ErrMissingQuestion struct{}
+
+func (e ErrMissingQuestion) Error() string {
+	return "missing question"
+}
+
+// ErrMissingPromptFile is returned when --prompt-file is not followed by a path
+type ErrMissingPromptFile struct{}
+
+func (e ErrMissingPromptFile) Error() string {
+	return "missing prompt file"
+}
+
+// ErrInvalidPromptPosition is a custom error type for unknown prompt positions
+type ErrInvalidPromptPosition struct {
+	Value string
+}
+
+func (e ErrInvalidPromptPosition) Error() string {
+	return fmt.Sprintf("invalid prompt position: %s (available: %s, %s)", e.Value, PromptBefore, PromptAfter)
+}
+
+// ErrInvalidReport is a custom error type for unknown report formats
+type ErrInvalidReport struct {
+	Value string
+}
+
+func (e ErrInvalidReport) Error() string {
+	return fmt.Sprintf("invalid report format: %s (available: %s)", e.Value, ReportFormatJSON)
+}
+
+// ErrConflictingFlags is returned for options that cannot be combined
+type ErrConflictingFlags struct {
+	First  string
+	Second string
+}
+
+func (e ErrConflictingFlags) Error() string {
+	return fmt.Sprintf("%s cannot be used with %s", e.First, e.Second)
+}
+
+// ErrInvalidDiffMode is a custom error type for unknown diff modes in the config file
+type ErrInvalidDiffMode struct {
+	Value string
+}
+
+func (e ErrInvalidDiffMode) Error() string {
+	return fmt.Sprintf("invalid diff mode: %s (available: %s, %s)", e.Value, DiffModeOnly, DiffModeFull)
+}
+
+// ErrUnknownCommand is returned for a subcommand that does not exist
+type ErrUnknownCommand struct {
+	Value string
+}
+
+func (e ErrUnknownCommand) Error() string {
+	return fmt.Sprintf("unknown command: %s", e.Value)
+}
+
+// ErrInvalidTop is a custom error type for invalid --top counts
+type ErrInvalidTop struct {
+	Value string
+}
+
+func (e ErrInvalidTop) Error() string {
+	return fmt.Sprintf("invalid top count: %s", e.Value)
+}
+
 func ValidateArgs(args []string) bool {
 	return len(args) > 0
 }
@@ -29,7 +147,7 @@ func ParseArgs(args []string) (Config, error) {
 		SpecificFiles: []string{},
 		Directories:   []string{},
 		Extensions:    []string{},
-		MaxFileSize:   500 * 1024, // Default to 500KB
+		MaxFileSize:   DefaultMaxFileSize,
 		GlobPatterns:  nil,
 		NoConfig:      false,
 	}
@@ -44,6 +162,35 @@ func ParseArgs(args []string) (Config, error) {
 		return config, nil
 	}
 
+	if args[0] == "config" {
+		if len(args) < 2 || args[1] != "validate" {
+			return config, ErrUnknownCommand{Value: strings.Join(args[:min(len(args), 2)], " ")}
+		}
+		config.Action = "validate_config"
+		config.ConfigFiles = args[2:]
+		return config, nil
+	}
+
+	if args[0] == "init" {
+		config.Action = "init"
+		for _, arg := range args[1:] {
+			switch arg {
+			case "--force":
+				config.Force = true
+			case "--stdout":
+				config.Stdout = true
+			default:
+				return config, ErrUnknownCommand{Value: "init " + arg}
+			}
+		}
+		return config, nil
+	}
+
+	if args[0] == "ls" {
+		config.DryRun = true
+		args = args[1:]
+	}
+
 	skipMode := false
 	extensionMode := false
 	globMode := false
@@ -57,6 +204,7 @@ func ParseArgs(args []string) (Config, error) {
 			return config, nil
 		case "--include-ignored":
 			config.IncludeIgnored = true
+			config.setFlag("include-ignored")
 		case "-s", "--skip":
 			skipMode = true
 		case "-e", "--extension":
@@ -70,12 +218,178 @@ func ParseArgs(args []string) (Config, error) {
 					return config, ErrInvalidMaxFileSize{Value: args[i+1]}
 				}
 				config.MaxFileSize = size
+				config.setFlag("max-filesize")
 				i++ // Skip the next argument as we've processed it
 			} else {
 				return config, ErrInvalidMaxFileSize{Value: ""}
 			}
 		case "-g", "--glob":
 			globMode = true
+		case "-t", "--max-tokens":
+			if i+1 >= len(args) {
+				return config, ErrInvalidMaxTokens{Value: ""}
+			}
+			tokens, err := strconv.Atoi(args[i+1])
+			if err != nil || tokens <= 0 {
+				return config, ErrInvalidMaxTokens{Value: args[i+1]}
+			}
+			config.MaxTokens = tokens
+			config.setFlag("max-tokens")
+			i++
+		case "--top":
+			if i+1 >= len(args) {
+				return config, ErrInvalidTop{Value: ""}
+			}
+			top, err := strconv.Atoi(args[i+1])
+			if err != nil || top <= 0 {
+				return config, ErrInvalidTop{Value: args[i+1]}
+			}
+			config.Top = top
+			config.setFlag("top")
+			i++
+		case "--split-tokens":
+			if i+1 >= len(args) {
+				return config, ErrInvalidSplitSize{Value: ""}
+			}
+			tokens, err := strconv.Atoi(args[i+1])
+			if err != nil || tokens <= 0 {
+				return config, ErrInvalidSplitSize{Value: args[i+1]}
+			}
+			config.SplitTokens = tokens
+			config.setFlag("split-tokens")
+			i++
+		case "--split-size":
+			if i+1 >= len(args) {
+				return config, ErrInvalidSplitSize{Value: ""}
+			}
+			size, err := parseFileSize(args[i+1])
+			if err != nil || size <= 0 {
+				return config, ErrInvalidSplitSize{Value: args[i+1]}
+			}
+			config.SplitSize = size
+			config.setFlag("split-size")
+			i++
+		case "--split-files":
+			config.SplitToFiles = true
+			config.setFlag("split-files")
+		case "--git-changed":
+			config.GitChanged = true
+			config.setFlag("git-changed")
+		case "--git-staged":
+			config.GitStaged = true
+			config.setFlag("git-staged")
+		case "--git-since":
+			if i+1 >= len(args) {
+				return config, ErrMissingGitRef{}
+			}
+			config.GitSince = args[i+1]
+			i++
+		case "--diff":
+			config.Diff = DiffModeOnly
+		case "--diff-full":
+			config.Diff = DiffModeFull
+		case "--diff-base":
+			if i+1 >= len(args) {
+				return config, ErrMissingGitRef{}
+			}
+			config.DiffBase = args[i+1]
+			i++
+		case "--stdout":
+			config.Stdout = true
+			config.setFlag("stdout")
+		case "--tree":
+			config.Tree = true
+			config.setFlag("tree")
+		case "--tree-ignored":
+			config.Tree = true
+			config.TreeIgnored = true
+			config.setFlag("tree", "tree-ignored")
+		case "--tree-only":
+			config.Tree = true
+			config.TreeOnly = true
+			config.setFlag("tree", "tree-only")
+		case "--line-numbers":
+			config.LineNumbers = true
+			config.setFlag("line-numbers")
+		case "--no-include-ignored", "--no-split-files", "--no-stdout", "--no-git-changed",
+			"--no-git-staged", "--no-tree", "--no-tree-ignored", "--no-tree-only", "--no-line-numbers":
+			config.turnOff(strings.TrimPrefix(arg, "--no-"))
+		case "--dry-run":
+			config.DryRun = true
+		case "-p", "--profile":
+			if i+1 >= len(args) {
+				return config, ErrMissingProfile{}
+			}
+			config.Profile = args[i+1]
+			i++
+		case "--template":
+			if i+1 >= len(args) {
+				return config, ErrMissingTemplate{}
+			}
+			config.Template = args[i+1]
+			i++
+		case "--ask":
+			if i+1 >= len(args) {
+				return config, ErrMissingQuestion{}
+			}
+			config.Question = args[i+1]
+			i++
+		case "--prompt-file":
+			if i+1 >= len(args) {
+				return config, ErrMissingPromptFile{}
+			}
+			config.PromptFile = args[i+1]
+			i++
+		case "--prompt-position":
+			if i+1 >= len(args) {
+				return config, ErrInvalidPromptPosition{Value: ""}
+			}
+			position := PromptPosition(args[i+1])
+			if position != PromptBefore && position != PromptAfter {
+				return config, ErrInvalidPromptPosition{Value: args[i+1]}
+			}
+			config.PromptPosition = position
+			i++
+		case "--report":
+			if i+1 >= len(args) {
+				return config, ErrInvalidReport{Value: ""}
+			}
+			if args[i+1] != ReportFormatJSON {
+				return config, ErrInvalidReport{Value: args[i+1]}
+			}
+			config.Report = args[i+1]
+			i++
+		case "--report-file":
+			if i+1 >= len(args) {
+				return config, ErrMissingOutputFile{}
+			}
+			config.Report = ReportFormatJSON
+			config.ReportFile = args[i+1]
+			i++
+		case "-o", "--output":
+			if i+1 >= len(args) {
+				return config, ErrMissingOutputFile{}
+			}
+			config.OutputFile = args[i+1]
+			i++
+		case "-f", "--format":
+			if i+1 >= len(args) {
+				return config, ErrInvalidFormat{Value: ""}
+			}
+			if _, err := NewFormatter(args[i+1]); err != nil {
+				return config, err
+			}
+			config.Format = args[i+1]
+			i++
+		case "--tokenizer":
+			if i+1 >= len(args) {
+				return config, ErrInvalidTokenizer{Value: ""}
+			}
+			if !IsTokenizerName(args[i+1]) {
+				return config, ErrInvalidTokenizer{Value: args[i+1]}
+			}
+			config.Tokenizer = args[i+1]
+			i++
 		default:
 			if skipMode {
 				config.AddSkipDir(arg)
@@ -86,16 +400,66 @@ func ParseArgs(args []string) (Config, error) {
 			} else if globMode {
 				config.GlobPatterns = append(config.GlobPatterns, arg)
 				globMode = false
-			} else {
-				if err := config.AddIncludePath(arg); err != nil {
-					fmt.Printf("Warning: Could not process path %s: %v\n", arg, err)
+			} else if err := config.AddIncludePath(arg); err != nil {
+				// A selector that cannot be used fails the run, like
+				// one that matches nothing in its file
+				var selectorErr ErrInvalidSelector
+				if errors.As(err, &selectorErr) {
+					return config, err
 				}
+				config.warnAboutPath(arg, err)
 			}
 		}
 	}
 
-	return config, nil
+	if config.NoConfig && config.Profile != "" {
+		return config, ErrConflictingFlags{First: "--profile", Second: "--no-config"}
+	}
+
+	// Listing the working directory is the most common dry run
+	if config.DryRun && len(config.Directories) == 0 && len(config.SpecificFiles) == 0 && !config.UsesGit() {
+		config.Directories = append(config.Directories, ".")
+	}
+
+	return config, checkOutputConflicts(config)
 }
+
+// checkOutputConflicts rejects output options that cannot be combined,
+// whether they came from the command line or the config file
+func checkOutputConflicts(config Config) error {
+	// Both would be written to stdout
+	if config.Stdout && config.Report != "" && config.ReportFile == "" {
+		return ErrConflictingFlags{First: "--stdout", Second: "--report json (use --report-file)"}
+	}
+	// A template wraps the whole dump, so it cannot be cut into parts
+	if config.Template != "" && (config.SplitTokens > 0 || config.SplitSize > 0) {
+		return ErrConflictingFlags{First: "--template", Second: "--split-tokens or --split-size"}
+	}
+	// Without contents there is nothing for a template to wrap
+	if config.TreeOnly && config.Template != "" {
+		return ErrConflictingFlags{First: "--tree-only", Second: "--template"}
+	}
+	// The budget is for contents. A max_tokens from a config file is
+	// left unused, but asking for both on the command line is a mistake.
+	if config.TreeOnly && config.FlagGiven("max-tokens") {
+		return ErrConflictingFlags{First: "--tree-only", Second: "--max-tokens (use --split-tokens)"}
+	}
+	if config.Question == "-" && config.PromptFile == "-" {
+		return ErrConflictingFlags{First: "--ask -", Second: "--prompt-file -"}
+	}
+	// Copying parts one at a time waits for Enter on stdin, which a
+	// question read from stdin has already used up
+	if config.SplitsInteractively() {
+		if config.Question == "-" {
+			return ErrConflictingFlags{First: "--ask -", Second: "splitting to the clipboard (use --split-files, --stdout or --output)"}
+		}
+		if config.PromptFile == "-" {
+			return ErrConflictingFlags{First: "--prompt-file -", Second: "splitting to the clipboard (use --split-files, --stdout or --output)"}
+		}
+	}
+	return nil
+}
+
 func parseFileSize(sizeStr string) (int64, error) {
 	sizeStr = strings.ToUpper(sizeStr)
 	var multiplier int64 = 1
@@ -0,0 +1,238 @@
+package src
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"embed"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// The vocabularies are compiled into the binary gzipped, so counting
+// works offline in every build
+//
+//go:embed vocab/*.tiktoken.gz
+var embeddedVocabularies embed.FS
+
+// whitespace is the Unicode White_Space class that \s means in the
+// original patterns. Go's \s only covers ASCII.
+const whitespace = `\t\n\v\f\r \x{85}\x{A0}\x{1680}\x{2000}-\x{200A}\x{2028}\x{2029}\x{202F}\x{205F}\x{3000}`
+
+// BPEEncoding describes a tiktoken encoding. The patterns are the
+// published ones with the \s+(?!\S) alternative removed, because Go's
+// regexp has no lookahead. BPETokenizer.Split makes up for it.
+// SHA256 pins the vocabulary file, the same checksum tiktoken checks.
+type BPEEncoding struct {
+	Name    string
+	SHA256  string
+	Pattern string
+}
+
+var Cl100kBase = BPEEncoding{
+	Name:   "cl100k_base",
+	SHA256: "223921b76ee99bde995b7ff738513eef100fb51d18c93597a113bcffe865b2a7",
+	Pattern: `(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}` +
+		`| ?[^` + whitespace + `\p{L}\p{N}]+[\r\n]*|[` + whitespace + `]*[\r\n]+|[` + whitespace + `]+`,
+}
+
+var O200kBase = BPEEncoding{
+	Name:   "o200k_base",
+	SHA256: "446a9538cb6c348e3516120d7c08b09f57c36495e2acfffe59a5bf8b0cfb1a2d",
+	Pattern: `[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+		`|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+		`|\p{N}{1,3}| ?[^` + whitespace + `\p{L}\p{N}]+[\r\n/]*|[` + whitespace + `]*[\r\n]+|[` + whitespace + `]+`,
+}
+
+// BPETokenizer counts tokens exactly like tiktoken's encode_ordinary,
+// by splitting text with the encoding's pattern and merging the bytes
+// of each piece by rank
+type BPETokenizer struct {
+	pattern *regexp.Regexp
+	ranks   map[string]int
+}
+
+var (
+	loadedTokenizers   = make(map[string]*BPETokenizer)
+	loadedTokenizersMu sync.Mutex
+)
+
+// LoadBPETokenizer loads the vocabulary of an encoding from the binary
+func LoadBPETokenizer(encoding BPEEncoding) (*BPETokenizer, error) {
+	loadedTokenizersMu.Lock()
+	defer loadedTokenizersMu.Unlock()
+	if tokenizer, ok := loadedTokenizers[encoding.Name]; ok {
+		return tokenizer, nil
+	}
+
+	vocabulary, err := readVocabulary(encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenizer, err := NewBPETokenizer(encoding.Pattern, bytes.NewReader(vocabulary))
+	if err != nil {
+		return nil, fmt.Errorf("loading %s vocabulary: %w", encoding.Name, err)
+	}
+	loadedTokenizers[encoding.Name] = tokenizer
+	return tokenizer, nil
+}
+
+// readVocabulary reads the embedded vocabulary of an encoding
+func readVocabulary(encoding BPEEncoding) ([]byte, error) {
+	compressed, err := embeddedVocabularies.Open("vocab/" + encoding.Name + ".tiktoken.gz")
+	if err != nil {
+		return nil, fmt.Errorf("the %s vocabulary is not compiled into this binary", encoding.Name)
+	}
+	defer compressed.Close()
+	return DecompressVocabulary(encoding, compressed)
+}
+
+// DecompressVocabulary unpacks a gzipped vocabulary and checks it against
+// the pinned checksum, so a truncated or altered file is not silently
+// used to count tokens
+func DecompressVocabulary(encoding BPEEncoding, compressed io.Reader) ([]byte, error) {
+	reader, err := gzip.NewReader(compressed)
+	if err != nil {
+		return nil, fmt.Errorf("reading the %s vocabulary: %w", encoding.Name, err)
+	}
+	vocabulary, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("reading the %s vocabulary: %w", encoding.Name, err)
+	}
+
+	sum := sha256.Sum256(vocabulary)
+	if encoding.SHA256 != "" && hex.EncodeToString(sum[:]) != encoding.SHA256 {
+		return nil, fmt.Errorf("the %s vocabulary does not match its sha256 checksum", encoding.Name)
+	}
+	return vocabulary, nil
+}
+
+// NewBPETokenizer builds a tokenizer from a pattern and a vocabulary in
+// the tiktoken format: one base64 encoded token and its rank per line
+func NewBPETokenizer(pattern string, vocabulary io.Reader) (*BPETokenizer, error) {
+	// Anchored, so each piece starts where the previous one ended
+	compiled, err := regexp.Compile(`\A(?:` + pattern + `)`)
+	if err != nil {
+		return nil, fmt.Errorf("compiling pattern: %w", err)
+	}
+
+	ranks := make(map[string]int)
+	scanner := bufio.NewScanner(vocabulary)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected a token and a rank", lineNumber)
+		}
+		token, err := base64.StdEncoding.DecodeString(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		rank, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		ranks[string(token)] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return &BPETokenizer{pattern: compiled, ranks: ranks}, nil
+}
+
+func (t *BPETokenizer) CountTokens(text string) int {
+	count := 0
+	for _, piece := range t.Split(text) {
+		if _, ok := t.ranks[piece]; ok {
+			count++
+			continue
+		}
+		count += len(t.mergePiece(piece))
+	}
+	return count
+}
+
+// Encode returns the token ranks for text
+func (t *BPETokenizer) Encode(text string) []int {
+	var tokens []int
+	for _, piece := range t.Split(text) {
+		if rank, ok := t.ranks[piece]; ok {
+			tokens = append(tokens, rank)
+			continue
+		}
+		for _, part := range t.mergePiece(piece) {
+			tokens = append(tokens, t.ranks[part])
+		}
+	}
+	return tokens
+}
+
+// Split breaks text into the pieces that are merged separately.
+// A run of spaces in front of a word leaves its last space to the
+// word, which is what \s+(?!\S) does in the original patterns.
+func (t *BPETokenizer) Split(text string) []string {
+	var pieces []string
+	for start := 0; start < len(text); {
+		match := t.pattern.FindStringIndex(text[start:])
+		if match == nil {
+			break
+		}
+		end := start + match[1]
+		piece := text[start:end]
+		if end < len(text) && isSpaceRun(piece) && utf8.RuneCountInString(piece) > 1 {
+			_, lastSize := utf8.DecodeLastRuneInString(piece)
+			end -= lastSize
+			piece = piece[:len(piece)-lastSize]
+		}
+		pieces = append(pieces, piece)
+		start = end
+	}
+	return pieces
+}
+
+// isSpaceRun reports whether a piece came from the final whitespace
+// alternative. Whitespace ending in a line break is matched earlier.
+func isSpaceRun(piece string) bool {
+	return spaceRun.MatchString(piece) && !strings.HasSuffix(piece, "\n") && !strings.HasSuffix(piece, "\r")
+}
+
+var spaceRun = regexp.MustCompile(`^[` + whitespace + `]+$`)
+
+// mergePiece repeatedly merges the adjacent pair with the lowest rank
+// until no pair is in the vocabulary
+func (t *BPETokenizer) mergePiece(piece string) []string {
+	parts := make([]string, len(piece))
+	for i := 0; i < len(piece); i++ {
+		parts[i] = piece[i : i+1]
+	}
+
+	for len(parts) > 1 {
+		bestRank, bestIndex := -1, -1
+		for i := 0; i < len(parts)-1; i++ {
+			if rank, ok := t.ranks[parts[i]+parts[i+1]]; ok && (bestRank == -1 || rank < bestRank) {
+				bestRank, bestIndex = rank, i
+			}
+		}
+		if bestIndex == -1 {
+			break
+		}
+		parts[bestIndex] += parts[bestIndex+1]
+		parts = append(parts[:bestIndex+1], parts[bestIndex+2:]...)
+	}
+	return parts
+}
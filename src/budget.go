@@ -0,0 +1,152 @@
+package src
+
+import (
+	"sort"
+	"strings"
+)
+
+// TokenBudget trims the processed files so the formatted output fits
+// within a model's context window
+type TokenBudget struct {
+	Config    Config
+	Formatter Formatter
+	Tokenizer Tokenizer
+}
+
+func NewTokenBudget(config Config, formatter Formatter, tokenizer Tokenizer) *TokenBudget {
+	return &TokenBudget{
+		Config:    config,
+		Formatter: formatter,
+		Tokenizer: tokenizer,
+	}
+}
+
+// Apply keeps files in priority order while they fit and marks the rest
+// as skipped. Explicitly named files and config file includes come first,
+// then smaller files, then more recently modified ones. The tree, the
+// question and the part headers are in the output whichever files fit,
+// so they count against the budget too.
+func (tb *TokenBudget) Apply(files []FileInfo, decisions []Decision) []FileInfo {
+	if tb.Config.MaxTokens <= 0 {
+		return files
+	}
+
+	type candidate struct {
+		fileInfo FileInfo
+		priority bool
+		tokens   int
+	}
+	candidates := make([]candidate, len(files))
+	for i, fileInfo := range files {
+		candidates[i] = candidate{
+			fileInfo: fileInfo,
+			priority: tb.isPriority(fileInfo.Path),
+			tokens:   tb.Tokenizer.CountTokens(FormatEntry(tb.Formatter, fileInfo)),
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.priority != b.priority {
+			return a.priority
+		}
+		if a.tokens != b.tokens {
+			return a.tokens < b.tokens
+		}
+		if !a.fileInfo.ModTime.Equal(b.fileInfo.ModTime) {
+			return a.fileInfo.ModTime.After(b.fileInfo.ModTime)
+		}
+		return a.fileInfo.Path < b.fileInfo.Path
+	})
+
+	// Start from the output with every file over the budget, so the tree
+	// notes all of them, and take a file's note back out when it is kept
+	kept := make([]bool, len(candidates))
+	selected := func() []FileInfo {
+		result := make([]FileInfo, len(candidates))
+		for i, c := range candidates {
+			result[i] = c.fileInfo
+			if !kept[i] {
+				result[i].Status = StatusSkippedOverBudget
+				result[i].Contents = ""
+			}
+		}
+		return result
+	}
+	usedTokens := tb.countReserved(selected(), decisions)
+	overBudgetNote := tb.treeNoteTokens(StatusSkippedOverBudget)
+	for i, c := range candidates {
+		cost := c.tokens - overBudgetNote + tb.treeNoteTokens(c.fileInfo.Status)
+		if usedTokens+cost <= tb.Config.MaxTokens {
+			usedTokens += cost
+			kept[i] = true
+		}
+	}
+
+	// The number of parts, and so of part headers, depends on the files
+	// kept, so the lowest priority ones go until the whole output fits
+	result := selected()
+	for last := len(kept) - 1; last >= 0; last-- {
+		if !kept[last] {
+			continue
+		}
+		if tb.countOutput(result, decisions) <= tb.Config.MaxTokens {
+			break
+		}
+		kept[last] = false
+		result = selected()
+	}
+	return result
+}
+
+// countReserved counts the output that is there whichever files are
+// kept: the tree and the question
+func (tb *TokenBudget) countReserved(files []FileInfo, decisions []Decision) int {
+	stats := Stats{ProcessedFiles: files}
+	tree := RenderTree(BuildTree(stats, decisions, false))
+	tokens := 0
+	for _, chunk := range NewTemplateData(stats, tb.Formatter, tree, tb.Config).Chunks() {
+		tokens += tb.Tokenizer.CountTokens(chunk)
+	}
+	return tokens
+}
+
+// countOutput counts the tokens of every part of the output the files
+// make, as PrintDetailedOutput copies it without a template
+func (tb *TokenBudget) countOutput(files []FileInfo, decisions []Decision) int {
+	stats := Stats{ProcessedFiles: SortFileList(files)}
+	tree := RenderTree(BuildTree(stats, decisions, false))
+	data := NewTemplateData(stats, tb.Formatter, tree, tb.Config)
+	tokens := 0
+	for _, part := range SplitIntoParts(data.Chunks(), tb.Config, tb.Tokenizer) {
+		tokens += tb.Tokenizer.CountTokens(part)
+	}
+	return tokens
+}
+
+// treeNoteTokens counts the note the tree adds after a file's name
+func (tb *TokenBudget) treeNoteTokens(status FileStatus) int {
+	if !tb.Config.Tree || treeNote(status) == "" {
+		return 0
+	}
+	return tb.Tokenizer.CountTokens(" (" + treeNote(status) + ")")
+}
+
+func (tb *TokenBudget) isPriority(path string) bool {
+	for _, file := range tb.Config.SpecificFiles {
+		if NormalizePath(file) == path {
+			return true
+		}
+	}
+	for _, priorityPath := range tb.Config.PriorityPaths {
+		if path == priorityPath || strings.HasPrefix(path, priorityPath+"/") {
+			return true
+		}
+	}
+	for _, pattern := range tb.Config.IncludeGlobs {
+		if glob, err := parseGlobPattern(pattern); err == nil && glob.matches(path) {
+			return true
+		}
+	}
+	return false
+}
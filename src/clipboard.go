@@ -1,6 +1,12 @@
 package src
 
 import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
 	"github.com/atotto/clipboard"
 )
 
@@ -8,6 +14,28 @@ type ClipboardManager interface {
 	WriteAll(string) error
 }
 
+// TerminalLimitedClipboard is a clipboard that goes through the
+// terminal, which may drop text above some size instead of copying it
+type TerminalLimitedClipboard interface {
+	ExceedsTerminalLimit(text string) bool
+}
+
+// NewClipboardManager picks the OSC 52 clipboard in SSH sessions, where
+// the system clipboard belongs to a machine without a display. Setting
+// DUMP_DIR_CLIPBOARD to "osc52" or "system" overrides the choice.
+func NewClipboardManager(getenv func(string) string) ClipboardManager {
+	switch getenv("DUMP_DIR_CLIPBOARD") {
+	case "osc52":
+		return NewOSC52Clipboard(getenv)
+	case "system":
+		return NewSystemClipboard()
+	}
+	if getenv("SSH_TTY") != "" {
+		return NewOSC52Clipboard(getenv)
+	}
+	return NewSystemClipboard()
+}
+
 type SystemClipboard struct{}
 
 func NewSystemClipboard() ClipboardManager {
@@ -17,3 +45,84 @@ func NewSystemClipboard() ClipboardManager {
 func (c *SystemClipboard) WriteAll(text string) error {
 	return clipboard.WriteAll(text)
 }
+
+const (
+	// screenChunkSize is the longest string GNU screen passes through in
+	// a single DCS sequence
+	screenChunkSize = 76
+	// tmuxChunkSize keeps each tmux passthrough sequence well below the
+	// size tmux buffers for a single escape sequence
+	tmuxChunkSize = 4096
+	// osc52PayloadLimit is roughly the largest encoded payload common
+	// terminals accept; xterm and several others drop anything longer
+	osc52PayloadLimit = 100_000
+)
+
+// OSC52Clipboard asks the terminal emulator to set the clipboard with
+// the OSC 52 escape sequence, so copying works over SSH and in containers
+// without xclip or wl-copy
+type OSC52Clipboard struct {
+	// Out is where the sequence is written. When nil, the controlling
+	// terminal is used, falling back to stderr.
+	Out    io.Writer
+	getenv func(string) string
+}
+
+func NewOSC52Clipboard(getenv func(string) string) *OSC52Clipboard {
+	return &OSC52Clipboard{getenv: getenv}
+}
+
+func (c *OSC52Clipboard) WriteAll(text string) error {
+	out := c.Out
+	if out == nil {
+		tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+		if err != nil {
+			out = os.Stderr
+		} else {
+			defer tty.Close()
+			out = tty
+		}
+	}
+
+	if _, err := io.WriteString(out, c.Sequence(text)); err != nil {
+		return fmt.Errorf("writing OSC 52 sequence: %w", err)
+	}
+	return nil
+}
+
+// ExceedsTerminalLimit reports whether the encoded text is larger than
+// what common terminals accept in one OSC 52 sequence
+func (c *OSC52Clipboard) ExceedsTerminalLimit(text string) bool {
+	return base64.StdEncoding.EncodedLen(len(text)) > osc52PayloadLimit
+}
+
+// Sequence builds the escape sequence, wrapped so tmux or screen
+// pass it through to the outer terminal
+func (c *OSC52Clipboard) Sequence(text string) string {
+	osc52 := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\x07"
+
+	switch {
+	case c.getenv("TMUX") != "":
+		return passthrough(osc52, tmuxChunkSize, "\x1bPtmux;", func(chunk string) string {
+			return strings.ReplaceAll(chunk, "\x1b", "\x1b\x1b")
+		})
+	case c.getenv("STY") != "" || strings.HasPrefix(c.getenv("TERM"), "screen"):
+		return passthrough(osc52, screenChunkSize, "\x1bP", func(chunk string) string {
+			return chunk
+		})
+	default:
+		return osc52
+	}
+}
+
+// passthrough splits the sequence into DCS passthrough chunks of at most
+// size bytes, escaping each chunk for the multiplexer
+func passthrough(osc52 string, size int, prefix string, escape func(string) string) string {
+	var sequence strings.Builder
+	for len(osc52) > 0 {
+		chunk := osc52[:min(size, len(osc52))]
+		osc52 = osc52[len(chunk):]
+		sequence.WriteString(prefix + escape(chunk) + "\x1b\\")
+	}
+	return sequence.String()
+}
@@ -1,38 +1,564 @@
 package src
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
 	"github.com/spf13/afero"
-	"gopkg.in/yaml.v3"
 )
 
 const ConfigFileName = ".dump_dir.yml"
 
+const DefaultProfile = "default"
+
+// ConfigFile is one layer of configuration: the project's .dump_dir.yml
+// or the user's config file
 type ConfigFile struct {
-	Include []string `yaml:"include,omitempty"`
-	Ignore  []string `yaml:"ignore,omitempty"`
+	Options `yaml:",inline"`
+	// Profile is the profile used when --profile is not given
+	Profile  string             `yaml:"profile,omitempty"`
+	Profiles map[string]Profile `yaml:"profiles,omitempty"`
+	// Templates are prompt templates written inline, by name
+	Templates map[string]string `yaml:"templates,omitempty"`
+}
+
+// Profile is a named set of options for one kind of task, selected with
+// --profile. Its options take precedence over the top level ones, and
+// its include and ignore lists add to them.
+type Profile struct {
+	Options `yaml:",inline"`
+}
+
+// Options are the settings a config file gives at the top level or in a
+// profile. Every command line option has one, apart from --no-config and
+// --dry-run, which only make sense for a single run. Options given on
+// the command line take precedence.
+type Options struct {
+	Paths          []string       `yaml:"paths,omitempty"`
+	Include        []string       `yaml:"include,omitempty"`
+	Ignore         []string       `yaml:"ignore,omitempty"`
+	Extensions     []string       `yaml:"extensions,omitempty"`
+	Globs          []string       `yaml:"globs,omitempty"`
+	IncludeIgnored *bool          `yaml:"include_ignored,omitempty"`
+	MaxFileSize    string         `yaml:"max_filesize,omitempty"`
+	Format         string         `yaml:"format,omitempty"`
+	Tokenizer      string         `yaml:"tokenizer,omitempty"`
+	MaxTokens      int            `yaml:"max_tokens,omitempty"`
+	Top            int            `yaml:"top,omitempty"`
+	SplitTokens    int            `yaml:"split_tokens,omitempty"`
+	SplitSize      string         `yaml:"split_size,omitempty"`
+	SplitFiles     *bool          `yaml:"split_files,omitempty"`
+	Output         string         `yaml:"output,omitempty"`
+	Stdout         *bool          `yaml:"stdout,omitempty"`
+	GitChanged     *bool          `yaml:"git_changed,omitempty"`
+	GitStaged      *bool          `yaml:"git_staged,omitempty"`
+	GitSince       string         `yaml:"git_since,omitempty"`
+	Diff           DiffMode       `yaml:"diff,omitempty"`
+	DiffBase       string         `yaml:"diff_base,omitempty"`
+	Report         string         `yaml:"report,omitempty"`
+	ReportFile     string         `yaml:"report_file,omitempty"`
+	Template       string         `yaml:"template,omitempty"`
+	Tree           *bool          `yaml:"tree,omitempty"`
+	TreeIgnored    *bool          `yaml:"tree_ignored,omitempty"`
+	TreeOnly       *bool          `yaml:"tree_only,omitempty"`
+	LineNumbers    *bool          `yaml:"line_numbers,omitempty"`
+	Ask            string         `yaml:"ask,omitempty"`
+	PromptFile     string         `yaml:"prompt_file,omitempty"`
+	PromptPosition PromptPosition `yaml:"prompt_position,omitempty"`
+}
+
+// ErrUnknownProfile is returned when --profile names a profile the
+// config file does not define
+type ErrUnknownProfile struct {
+	Name      string
+	Available []string
+}
+
+func (e ErrUnknownProfile) Error() string {
+	if len(e.Available) == 0 {
+		return fmt.Sprintf("unknown profile: %s (%s defines no profiles)", e.Name, ConfigFileName)
+	}
+	return fmt.Sprintf("unknown profile: %s (available: %s)", e.Name, strings.Join(e.Available, ", "))
 }
 
 type ConfigLoader struct {
 	fs afero.Fs
+	// UserConfigPath is the user's own config file, which the project's
+	// config file takes precedence over
+	UserConfigPath string
 }
 
 func NewConfigLoader(fs afero.Fs) *ConfigLoader {
-	return &ConfigLoader{fs: fs}
+	return &ConfigLoader{fs: fs, UserConfigPath: UserConfigFilePath()}
 }
 
+// UserConfigFilePath is where the user's config file is looked up:
+// $XDG_CONFIG_HOME/dump_dir/config.yml, or ~/.config/dump_dir/config.yml
+func UserConfigFilePath() string {
+	if configHome := os.Getenv("XDG_CONFIG_HOME"); configHome != "" {
+		return filepath.Join(configHome, "dump_dir", "config.yml")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "dump_dir", "config.yml")
+}
+
+// configLayer is a config file to load, with the directory its relative
+// paths are resolved against
+type configLayer struct {
+	fsPath string
+	dir    string
+}
+
+// findConfigLayers returns the user's config file followed by the
+// .dump_dir.yml files from the repository root down to the working
+// directory, so nearer files take precedence. Outside a repository only
+// the working directory is searched.
+func (cl *ConfigLoader) findConfigLayers() []configLayer {
+	var layers []configLayer
+	if cl.UserConfigPath != "" {
+		layers = append(layers, configLayer{fsPath: cl.UserConfigPath, dir: filepath.Dir(cl.UserConfigPath)})
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return append(layers, configLayer{fsPath: ConfigFileName})
+	}
+
+	var project []configLayer
+	dir, fsPath := cwd, "."
+	for {
+		project = append(project, configLayer{fsPath: filepath.Join(fsPath, ConfigFileName), dir: dir})
+		if exists, _ := afero.Exists(cl.fs, filepath.Join(fsPath, ".git")); exists {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			// Not in a repository
+			project = project[:1]
+			break
+		}
+		dir, fsPath = parent, filepath.Join(fsPath, "..")
+	}
+
+	for i := len(project) - 1; i >= 0; i-- {
+		layers = append(layers, project[i])
+	}
+	return layers
+}
+
+// LoadAndMergeConfig applies the config files to the command line
+// options. The command line takes precedence over the project's config
+// files, then the user's config file, then the defaults. Within each
+// file the selected profile takes precedence over the top level options.
 func (cl *ConfigLoader) LoadAndMergeConfig(cmdConfig Config) (Config, error) {
 	if cmdConfig.NoConfig {
 		return cmdConfig, nil
 	}
-	configFile, err := cl.loadConfigFile()
-	if err != nil {
-		return cmdConfig, fmt.Errorf("error loading config file: %w", err)
+
+	cwd, _ := os.Getwd()
+	var layers []ConfigFile
+	for _, layer := range cl.findConfigLayers() {
+		configFile, err := cl.loadConfigFile(layer.fsPath)
+		if err != nil {
+			return cmdConfig, err
+		}
+		if configFile != nil {
+			layers = append(layers, configFile.resolvePaths(layer.dir, cwd))
+		}
 	}
-	if configFile == nil {
+	if len(layers) == 0 {
+		if cmdConfig.Profile != "" {
+			return cmdConfig, ErrUnknownProfile{Name: cmdConfig.Profile}
+		}
 		return cmdConfig, nil
 	}
-	return MergeConfigs(cmdConfig, *configFile), nil
+
+	configFile := layers[0]
+	for _, layer := range layers[1:] {
+		configFile = configFile.overriddenBy(layer)
+	}
+
+	name := cmdConfig.Profile
+	if name == "" {
+		name = configFile.Profile
+	}
+	profile, err := configFile.SelectProfile(name)
+	if err != nil {
+		return cmdConfig, err
+	}
+
+	if profile != nil {
+		if name == "" {
+			name = DefaultProfile
+		}
+		cmdConfig.Profile = name
+	}
+
+	// Each file's profile is applied to that file's options before the
+	// files are layered, so a profile in the user file cannot override
+	// the top level options of a project file
+	options := layers[0].withProfile(name)
+	for _, layer := range layers[1:] {
+		options = options.overriddenBy(layer.withProfile(name))
+	}
+
+	if len(configFile.Templates) > 0 {
+		cmdConfig.Templates = configFile.Templates
+	}
+	config := ApplyOptions(cmdConfig, options)
+	config = MergeConfigs(config, ConfigFile{Options: options})
+	return config, checkOutputConflicts(config)
+}
+
+// ValidateConfigFiles checks the given config files, or the ones that
+// would be loaded, and prints every problem found
+func (cl *ConfigLoader) ValidateConfigFiles(paths []string, console io.Writer) error {
+	if len(paths) == 0 {
+		for _, layer := range cl.findConfigLayers() {
+			if exists, _ := afero.Exists(cl.fs, layer.fsPath); exists {
+				paths = append(paths, layer.fsPath)
+			}
+		}
+		if len(paths) == 0 {
+			fmt.Fprintln(console, boldCyan("No config files found."))
+			return nil
+		}
+	}
+
+	problems := 0
+	for _, path := range paths {
+		err := ValidateConfigFile(cl.fs, path)
+		var configErrors ConfigErrors
+		switch {
+		case err == nil:
+			fmt.Fprintln(console, BoldGreen(fmt.Sprintf("✅ %s is valid", path)))
+			continue
+		case errors.As(err, &configErrors):
+			for _, configError := range configErrors {
+				fmt.Fprintln(console, boldRed("❌ "+configError.Error()))
+			}
+			problems += len(configErrors)
+		default:
+			fmt.Fprintln(console, boldRed(fmt.Sprintf("❌ %v", err)))
+			problems++
+		}
+	}
+	if problems > 0 {
+		return fmt.Errorf("found %d problem(s) in config files", problems)
+	}
+	return nil
+}
+
+// SelectProfile returns the named profile, or the default profile when
+// no name is given. It returns nil when there is no profile to apply.
+func (cf *ConfigFile) SelectProfile(name string) (*Profile, error) {
+	if name == "" {
+		if profile, ok := cf.Profiles[DefaultProfile]; ok {
+			return &profile, nil
+		}
+		return nil, nil
+	}
+
+	profile, ok := cf.Profiles[name]
+	if !ok {
+		available := make([]string, 0, len(cf.Profiles))
+		for profileName := range cf.Profiles {
+			available = append(available, profileName)
+		}
+		sort.Strings(available)
+		return nil, ErrUnknownProfile{Name: name, Available: available}
+	}
+	return &profile, nil
+}
+
+// withProfile returns the top level options of a single config file
+// with its version of the named profile, if it has one, applied on top
+func (cf ConfigFile) withProfile(name string) Options {
+	profile, ok := cf.Profiles[firstSet(name, DefaultProfile)]
+	if !ok {
+		return cf.Options
+	}
+	return cf.Options.overriddenBy(profile.Options)
+}
+
+// resolvePaths rewrites the relative paths of a layer, which are
+// relative to the directory of its file, to be relative to the working
+// directory
+func (cf ConfigFile) resolvePaths(dir, cwd string) ConfigFile {
+	if dir == "" || dir == cwd {
+		return cf
+	}
+	resolved := cf
+	resolved.Options = cf.Options.resolvePaths(dir, cwd)
+	resolved.Profiles = make(map[string]Profile, len(cf.Profiles))
+	for name, profile := range cf.Profiles {
+		resolved.Profiles[name] = Profile{Options: profile.Options.resolvePaths(dir, cwd)}
+	}
+	return resolved
+}
+
+func (o Options) resolvePaths(dir, cwd string) Options {
+	resolveEntries := func(entries []string) []string {
+		if entries == nil {
+			return nil
+		}
+		resolved := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			if IsGlob(entry) {
+				resolved = append(resolved, resolveGlob(entry, dir, cwd))
+			} else {
+				resolved = append(resolved, resolvePath(entry, dir, cwd))
+			}
+		}
+		return resolved
+	}
+
+	resolved := o
+	resolved.Paths = resolveEntries(o.Paths)
+	resolved.Include = resolveEntries(o.Include)
+	resolved.Ignore = resolveEntries(o.Ignore)
+	if o.Globs != nil {
+		resolved.Globs = make([]string, 0, len(o.Globs))
+		for _, pattern := range o.Globs {
+			resolved.Globs = append(resolved.Globs, resolveGlob(pattern, dir, cwd))
+		}
+	}
+	if o.Output != "" {
+		resolved.Output = resolvePath(o.Output, dir, cwd)
+	}
+	if o.ReportFile != "" {
+		resolved.ReportFile = resolvePath(o.ReportFile, dir, cwd)
+	}
+	if o.PromptFile != "" && o.PromptFile != "-" {
+		resolved.PromptFile = resolvePath(o.PromptFile, dir, cwd)
+	}
+	if strings.Contains(o.Template, "/") || strings.HasSuffix(o.Template, TemplateExtension) {
+		resolved.Template = resolvePath(o.Template, dir, cwd)
+	}
+	return resolved
+}
+
+// resolvePath makes a path from a config file in dir usable from the
+// working directory. Paths from a parent directory become relative to
+// the working directory, and paths from elsewhere, like the user's
+// config directory, become absolute.
+func resolvePath(entry, dir, cwd string) string {
+	if expanded, err := expandHomeDir(entry); err == nil {
+		entry = expanded
+	}
+	if filepath.IsAbs(entry) {
+		return entry
+	}
+	resolved := filepath.Join(dir, entry)
+	if !isAncestor(dir, cwd) {
+		return resolved
+	}
+	if rel, err := filepath.Rel(cwd, resolved); err == nil {
+		return NormalizePath(filepath.ToSlash(rel))
+	}
+	return resolved
+}
+
+// resolveGlob rebases a glob pattern from a config file in dir onto the
+// working directory. Patterns matching a file name, or starting with
+// "**", match the same files from anywhere and are kept as they are.
+func resolveGlob(pattern, dir, cwd string) string {
+	prefix := ""
+	if strings.HasPrefix(pattern, "!") {
+		prefix, pattern = "!", pattern[1:]
+	}
+	trimmed := strings.TrimPrefix(pattern, "./")
+	if !strings.Contains(trimmed, "/") || strings.HasPrefix(trimmed, "**/") {
+		return prefix + pattern
+	}
+	if !isAncestor(dir, cwd) {
+		return prefix + filepath.ToSlash(filepath.Join(dir, trimmed))
+	}
+
+	// Drop the directories between dir and the working directory from
+	// the front of the pattern
+	rel, _ := filepath.Rel(dir, cwd)
+	segments := strings.Split(trimmed, "/")
+	for _, cwdSegment := range strings.Split(filepath.ToSlash(rel), "/") {
+		if segments[0] == "**" {
+			break
+		}
+		matched, _ := path.Match(segments[0], cwdSegment)
+		if !matched || len(segments) == 1 {
+			// The pattern matches outside the working directory
+			up, _ := filepath.Rel(cwd, dir)
+			return prefix + filepath.ToSlash(filepath.Join(up, trimmed))
+		}
+		segments = segments[1:]
+	}
+	return prefix + "./" + strings.Join(segments, "/")
+}
+
+func isAncestor(dir, cwd string) bool {
+	rel, err := filepath.Rel(dir, cwd)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+// overriddenBy merges a higher precedence layer into this one. Profiles
+// with the same name are replaced rather than merged.
+func (cf ConfigFile) overriddenBy(higher ConfigFile) ConfigFile {
+	merged := ConfigFile{
+		Options:   cf.Options.overriddenBy(higher.Options),
+		Profile:   firstSet(higher.Profile, cf.Profile),
+		Profiles:  make(map[string]Profile, len(cf.Profiles)+len(higher.Profiles)),
+		Templates: make(map[string]string, len(cf.Templates)+len(higher.Templates)),
+	}
+	for name, text := range cf.Templates {
+		merged.Templates[name] = text
+	}
+	for name, text := range higher.Templates {
+		merged.Templates[name] = text
+	}
+	for name, profile := range cf.Profiles {
+		merged.Profiles[name] = profile
+	}
+	for name, profile := range higher.Profiles {
+		merged.Profiles[name] = profile
+	}
+	return merged
+}
+
+// overriddenBy merges higher precedence options into these. The include
+// and ignore lists add up, and every other option is replaced when set.
+func (o Options) overriddenBy(higher Options) Options {
+	return Options{
+		Paths:          firstList(higher.Paths, o.Paths),
+		Include:        append(append([]string{}, o.Include...), higher.Include...),
+		Ignore:         append(append([]string{}, o.Ignore...), higher.Ignore...),
+		Extensions:     firstList(higher.Extensions, o.Extensions),
+		Globs:          firstList(higher.Globs, o.Globs),
+		IncludeIgnored: firstSet(higher.IncludeIgnored, o.IncludeIgnored),
+		MaxFileSize:    firstSet(higher.MaxFileSize, o.MaxFileSize),
+		Format:         firstSet(higher.Format, o.Format),
+		Tokenizer:      firstSet(higher.Tokenizer, o.Tokenizer),
+		MaxTokens:      firstSet(higher.MaxTokens, o.MaxTokens),
+		Top:            firstSet(higher.Top, o.Top),
+		SplitTokens:    firstSet(higher.SplitTokens, o.SplitTokens),
+		SplitSize:      firstSet(higher.SplitSize, o.SplitSize),
+		SplitFiles:     firstSet(higher.SplitFiles, o.SplitFiles),
+		Output:         firstSet(higher.Output, o.Output),
+		Stdout:         firstSet(higher.Stdout, o.Stdout),
+		GitChanged:     firstSet(higher.GitChanged, o.GitChanged),
+		GitStaged:      firstSet(higher.GitStaged, o.GitStaged),
+		GitSince:       firstSet(higher.GitSince, o.GitSince),
+		Diff:           firstSet(higher.Diff, o.Diff),
+		DiffBase:       firstSet(higher.DiffBase, o.DiffBase),
+		Report:         firstSet(higher.Report, o.Report),
+		ReportFile:     firstSet(higher.ReportFile, o.ReportFile),
+		Template:       firstSet(higher.Template, o.Template),
+		Tree:           firstSet(higher.Tree, o.Tree),
+		TreeIgnored:    firstSet(higher.TreeIgnored, o.TreeIgnored),
+		TreeOnly:       firstSet(higher.TreeOnly, o.TreeOnly),
+		LineNumbers:    firstSet(higher.LineNumbers, o.LineNumbers),
+		Ask:            firstSet(higher.Ask, o.Ask),
+		PromptFile:     firstSet(higher.PromptFile, o.PromptFile),
+		PromptPosition: firstSet(higher.PromptPosition, o.PromptPosition),
+	}
+}
+
+func firstSet[T comparable](values ...T) T {
+	var zero T
+	for _, value := range values {
+		if value != zero {
+			return value
+		}
+	}
+	return zero
+}
+
+func firstList(lists ...[]string) []string {
+	for _, list := range lists {
+		if len(list) > 0 {
+			return list
+		}
+	}
+	return nil
+}
+
+// ApplyOptions fills in the options the command line left unset. A
+// switch given on the command line, on or off with --no-<switch>, wins
+// over the config file. The values were checked when the config files
+// were decoded.
+func ApplyOptions(cmdConfig Config, options Options) Config {
+	config := cmdConfig
+
+	if len(config.Directories) == 0 && len(config.SpecificFiles) == 0 {
+		for _, path := range options.Paths {
+			config.addIncludePathOrWarn(path)
+		}
+	}
+	if len(config.Extensions) == 0 {
+		config.Extensions = append(config.Extensions, options.Extensions...)
+	}
+	if len(config.GlobPatterns) == 0 {
+		config.GlobPatterns = append(config.GlobPatterns, options.Globs...)
+	}
+	config.applySwitch("include-ignored", &config.IncludeIgnored, options.IncludeIgnored)
+	config.applySwitch("split-files", &config.SplitToFiles, options.SplitFiles)
+	config.applySwitch("stdout", &config.Stdout, options.Stdout)
+	config.applySwitch("git-changed", &config.GitChanged, options.GitChanged)
+	config.applySwitch("git-staged", &config.GitStaged, options.GitStaged)
+	config.applySwitch("tree", &config.Tree, options.Tree)
+	config.applySwitch("tree-ignored", &config.TreeIgnored, options.TreeIgnored)
+	config.applySwitch("tree-only", &config.TreeOnly, options.TreeOnly)
+	config.applySwitch("line-numbers", &config.LineNumbers, options.LineNumbers)
+	config.Tree = config.Tree || config.TreeIgnored || config.TreeOnly
+
+	if config.MaxFileSize == DefaultMaxFileSize && !config.FlagGiven("max-filesize") && options.MaxFileSize != "" {
+		config.MaxFileSize, _ = parseFileSize(options.MaxFileSize)
+	}
+	if config.SplitSize == 0 && !config.FlagGiven("split-size") && options.SplitSize != "" {
+		config.SplitSize, _ = parseFileSize(options.SplitSize)
+	}
+	config.Format = firstSet(config.Format, options.Format)
+	config.Tokenizer = firstSet(config.Tokenizer, options.Tokenizer)
+	if config.MaxTokens == 0 && !config.FlagGiven("max-tokens") {
+		config.MaxTokens = options.MaxTokens
+	}
+	if config.Top == 0 && !config.FlagGiven("top") {
+		config.Top = options.Top
+	}
+	if config.SplitTokens == 0 && !config.FlagGiven("split-tokens") {
+		config.SplitTokens = options.SplitTokens
+	}
+	if config.Diff == DiffModeNone {
+		config.Diff = options.Diff
+	}
+	config.Report = firstSet(config.Report, options.Report)
+	if config.ReportFile == "" && options.ReportFile != "" {
+		config.Report = ReportFormatJSON
+		config.ReportFile = options.ReportFile
+	}
+	config.OutputFile = firstSet(config.OutputFile, options.Output)
+	config.GitSince = firstSet(config.GitSince, options.GitSince)
+	config.DiffBase = firstSet(config.DiffBase, options.DiffBase)
+	if config.PromptPosition == "" {
+		config.PromptPosition = options.PromptPosition
+	}
+	config.Template = firstSet(config.Template, options.Template)
+	config.Question = firstSet(config.Question, options.Ask)
+	config.PromptFile = firstSet(config.PromptFile, options.PromptFile)
+	return config
+}
+
+func (c *Config) applySwitch(flag string, value *bool, option *bool) {
+	if !*value && !c.FlagGiven(flag) && option != nil {
+		*value = *option
+	}
 }
 
 func MergeConfigs(cmdConfig Config, fileConfig ConfigFile) Config {
@@ -40,23 +566,38 @@ func MergeConfigs(cmdConfig Config, fileConfig ConfigFile) Config {
 
 	if fileConfig.Ignore != nil {
 		for _, ignorePath := range fileConfig.Ignore {
+			if IsGlob(ignorePath) {
+				mergedConfig.GlobPatterns = append(mergedConfig.GlobPatterns, "!"+ignorePath)
+				mergedConfig.IgnorePaths = append(mergedConfig.IgnorePaths, ignorePath)
+				continue
+			}
 			mergedConfig.AddSkipDir(ignorePath)
+			mergedConfig.IgnorePaths = append(mergedConfig.IgnorePaths, NormalizePath(ignorePath))
 		}
 	}
 
 	if fileConfig.Include != nil {
 		for _, includePath := range fileConfig.Include {
-			if err := mergedConfig.AddIncludePath(includePath); err != nil {
-				fmt.Printf("Warning: Could not process path %s: %v\n", includePath, err)
+			if IsGlob(includePath) {
+				mergedConfig.IncludeGlobs = append(mergedConfig.IncludeGlobs, includePath)
+				continue
 			}
+			if !mergedConfig.addIncludePathOrWarn(includePath) {
+				continue
+			}
+			selectedPath, _, _ := ParseFileSelector(includePath)
+			mergedConfig.PriorityPaths = append(mergedConfig.PriorityPaths, NormalizePath(selectedPath))
 		}
 	}
 
 	return mergedConfig
 }
 
-func (cl *ConfigLoader) loadConfigFile() (*ConfigFile, error) {
-	exists, err := afero.Exists(cl.fs, ConfigFileName)
+func (cl *ConfigLoader) loadConfigFile(path string) (*ConfigFile, error) {
+	if path == "" {
+		return nil, nil
+	}
+	exists, err := afero.Exists(cl.fs, path)
 	if err != nil {
 		return nil, fmt.Errorf("error checking config file existence: %w", err)
 	}
@@ -65,16 +606,10 @@ func (cl *ConfigLoader) loadConfigFile() (*ConfigFile, error) {
 		return nil, nil
 	}
 
-	data, err := afero.ReadFile(cl.fs, ConfigFileName)
-	if err != nil {
-		return nil, fmt.Errorf("error reading config file: %w", err)
-	}
-
-	var config ConfigFile
-	err = yaml.Unmarshal(data, &config)
+	data, err := afero.ReadFile(cl.fs, path)
 	if err != nil {
-		return nil, fmt.Errorf("error parsing config file: %w", err)
+		return nil, fmt.Errorf("error reading config file %s: %w", path, err)
 	}
 
-	return &config, nil
+	return DecodeConfigFile(path, data)
 }
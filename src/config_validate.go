@@ -0,0 +1,364 @@
+package src
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigError is a problem at a position in a config file
+type ConfigError struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e ConfigError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Message)
+}
+
+// ConfigErrors are all the problems found in a config file
+type ConfigErrors []ConfigError
+
+func (e ConfigErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, configError := range e {
+		messages[i] = configError.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// DecodeConfigFile parses a config file strictly. Unknown keys, keys
+// given twice and values of the wrong type are reported with their
+// positions, instead of being dropped.
+func DecodeConfigFile(path string, data []byte) (*ConfigFile, error) {
+	root, err := parseConfigDocument(path, data)
+	if err != nil || root == nil {
+		return &ConfigFile{}, err
+	}
+
+	validator := configValidator{file: path}
+	validator.validate(root, reflect.TypeOf(ConfigFile{}), "")
+	if len(validator.errors) > 0 {
+		return nil, validator.errors
+	}
+
+	var config ConfigFile
+	if err := root.Decode(&config); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &config, nil
+}
+
+// ValidateConfigFile checks a config file like DecodeConfigFile does,
+// and also reports include paths that do not exist
+func ValidateConfigFile(fs afero.Fs, path string) error {
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return err
+	}
+	root, err := parseConfigDocument(path, data)
+	if err != nil || root == nil {
+		return err
+	}
+
+	validator := configValidator{file: path, fs: fs, dir: filepath.Dir(path)}
+	validator.validate(root, reflect.TypeOf(ConfigFile{}), "")
+	if len(validator.errors) > 0 {
+		return validator.errors
+	}
+	return nil
+}
+
+// parseConfigDocument returns the top level node of a config file, or
+// nil when the file is empty
+func parseConfigDocument(path string, data []byte) (*yaml.Node, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(document.Content) == 0 || document.Content[0].Tag == "!!null" {
+		return nil, nil
+	}
+	return document.Content[0], nil
+}
+
+type configValidator struct {
+	file   string
+	errors ConfigErrors
+	// fs is set to check that include paths exist, relative to dir
+	fs  afero.Fs
+	dir string
+}
+
+func (v *configValidator) addError(node *yaml.Node, format string, args ...interface{}) {
+	v.errors = append(v.errors, ConfigError{
+		File:    v.file,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// validate checks a node against the Go type it will be decoded into
+func (v *configValidator) validate(node *yaml.Node, t reflect.Type, key string) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Tag == "!!null" {
+		return
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			v.addError(node, "%s: expected a mapping, got %s", describeKey(key), describeNode(node))
+			return
+		}
+		fields := yamlFields(t)
+		v.validateMapping(node, func(name string, keyNode, valueNode *yaml.Node) {
+			fieldType, ok := fields[name]
+			if !ok {
+				v.addError(keyNode, "unknown key %q%s", name, suggestKey(name, fields))
+				return
+			}
+			v.validate(valueNode, fieldType, name)
+		})
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			v.addError(node, "%s: expected a mapping, got %s", key, describeNode(node))
+			return
+		}
+		v.validateMapping(node, func(name string, keyNode, valueNode *yaml.Node) {
+			v.validate(valueNode, t.Elem(), key+"."+name)
+		})
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			v.addError(node, "%s: expected a list, got %s", key, describeNode(node))
+			return
+		}
+		for _, item := range node.Content {
+			if item.Kind != yaml.ScalarNode || item.Tag == "!!null" {
+				v.addError(item, "%s: expected a string, got %s", key, describeNode(item))
+				continue
+			}
+			v.checkPath(item, key)
+		}
+	case reflect.String:
+		if node.Kind != yaml.ScalarNode {
+			v.addError(node, "%s: expected a string, got %s", key, describeNode(node))
+			return
+		}
+		v.checkValue(node, key)
+	case reflect.Int:
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!int" {
+			v.addError(node, "%s: expected a number, got %s", key, describeNode(node))
+			return
+		}
+		v.checkValue(node, key)
+	case reflect.Bool:
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!bool" {
+			v.addError(node, "%s: expected true or false, got %s", key, describeNode(node))
+		}
+	}
+}
+
+// validateMapping reports keys that are not strings or appear twice,
+// and calls check for every other entry
+func (v *configValidator) validateMapping(node *yaml.Node, check func(name string, keyNode, valueNode *yaml.Node)) {
+	seen := make(map[string]int)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		if keyNode.Kind != yaml.ScalarNode {
+			v.addError(keyNode, "expected a key, got %s", describeNode(keyNode))
+			continue
+		}
+		name := keyNode.Value
+		if line, ok := seen[name]; ok {
+			v.addError(keyNode, "duplicate key %q, first given on line %d", name, line)
+			continue
+		}
+		seen[name] = keyNode.Line
+		check(name, keyNode, valueNode)
+	}
+}
+
+// checkValue reports option values that dump_dir would refuse, such as
+// an unknown format or a size it cannot parse
+func (v *configValidator) checkValue(node *yaml.Node, key string) {
+	if err := checkOptionValue(key, node.Value); err != nil {
+		v.addError(node, "%s: %v", key, err)
+	}
+}
+
+// checkOptionValue checks the value of the option with the given key.
+// An empty value leaves the option unset.
+func checkOptionValue(key, value string) error {
+	if value == "" {
+		return nil
+	}
+	switch key {
+	case "max_filesize":
+		if size, err := parseFileSize(value); err != nil || size <= 0 {
+			return ErrInvalidMaxFileSize{Value: value}
+		}
+	case "split_size":
+		if size, err := parseFileSize(value); err != nil || size <= 0 {
+			return ErrInvalidSplitSize{Value: value}
+		}
+	case "format":
+		if _, err := NewFormatter(value); err != nil {
+			return err
+		}
+	case "tokenizer":
+		if !IsTokenizerName(value) {
+			return ErrInvalidTokenizer{Value: value}
+		}
+	case "max_tokens":
+		if count, err := strconv.Atoi(value); err != nil || count < 0 {
+			return ErrInvalidMaxTokens{Value: value}
+		}
+	case "top":
+		if count, err := strconv.Atoi(value); err != nil || count < 0 {
+			return ErrInvalidTop{Value: value}
+		}
+	case "split_tokens":
+		if count, err := strconv.Atoi(value); err != nil || count < 0 {
+			return ErrInvalidSplitSize{Value: value}
+		}
+	case "diff":
+		if mode := DiffMode(value); mode != DiffModeOnly && mode != DiffModeFull {
+			return ErrInvalidDiffMode{Value: value}
+		}
+	case "report":
+		if value != ReportFormatJSON {
+			return ErrInvalidReport{Value: value}
+		}
+	case "prompt_position":
+		if position := PromptPosition(value); position != PromptBefore && position != PromptAfter {
+			return ErrInvalidPromptPosition{Value: value}
+		}
+	}
+	return nil
+}
+
+// checkPath reports include and paths entries that do not exist
+func (v *configValidator) checkPath(node *yaml.Node, key string) {
+	if v.fs == nil || (key != "include" && key != "paths") || IsGlob(node.Value) {
+		return
+	}
+	path, err := expandHomeDir(node.Value)
+	if err != nil {
+		return
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(v.dir, path)
+	}
+	if exists, _ := afero.Exists(v.fs, path); exists {
+		return
+	}
+	// An include can select part of a file, like main.go:40-120
+	selectedPath, _, ok, err := SplitFileSelector(path)
+	if err != nil {
+		v.addError(node, "%s: %v", key, err)
+		return
+	}
+	if exists, _ := afero.Exists(v.fs, selectedPath); !ok || !exists {
+		v.addError(node, "%s: %s does not exist", key, node.Value)
+	}
+}
+
+// yamlFields maps the YAML keys of a struct to their types, including
+// the keys of inlined structs
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if strings.Contains(options, "inline") {
+			for inlineName, inlineType := range yamlFields(field.Type) {
+				fields[inlineName] = inlineType
+			}
+			continue
+		}
+		if name != "" && name != "-" {
+			fields[name] = field.Type
+		}
+	}
+	return fields
+}
+
+// suggestKey names the closest known key to a typo
+func suggestKey(name string, fields map[string]reflect.Type) string {
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+
+	best, bestDistance := "", 3
+	for _, field := range names {
+		if distance := editDistance(name, field); distance < bestDistance {
+			best, bestDistance = field, distance
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %q?)", best)
+}
+
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+func describeKey(key string) string {
+	if key == "" {
+		return "config file"
+	}
+	return key
+}
+
+func describeNode(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a list"
+	}
+	switch node.Tag {
+	case "!!null":
+		return "nothing"
+	case "!!int", "!!float":
+		return fmt.Sprintf("the number %s", node.Value)
+	case "!!bool":
+		return node.Value
+	}
+	return fmt.Sprintf("%q", node.Value)
+}
@@ -0,0 +1,28 @@
+package src
+
+import (
+	"io"
+	"os"
+)
+
+// Output returns where file contents, the dry run and the report are
+// written when they go to stdout
+func (rc RunConfig) Output() io.Writer {
+	if rc.Stdout != nil {
+		return rc.Stdout
+	}
+	return os.Stdout
+}
+
+// Console returns where human-oriented messages should be written. They
+// go to stderr when file contents or the report are written to stdout,
+// so they do not end up in the pipe.
+func (rc RunConfig) Console(config Config) io.Writer {
+	if !config.DiagnosticsToStderr() {
+		return rc.Output()
+	}
+	if rc.Stderr != nil {
+		return rc.Stderr
+	}
+	return os.Stderr
+}
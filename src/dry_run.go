@@ -0,0 +1,76 @@
+package src
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PrintDryRun lists every file the finder looked at and the rule that
+// decided whether it is dumped, without reading any file contents
+func PrintDryRun(decisions []Decision, config Config, runConfig RunConfig) {
+	decisions = mergeDecisions(decisions)
+
+	pathWidth := 0
+	for _, decision := range decisions {
+		pathWidth = max(pathWidth, len(displayPath(decision)))
+	}
+
+	var output strings.Builder
+	included, excluded, skippedDirs := 0, 0, 0
+	for _, decision := range decisions {
+		marker := "❌"
+		reason := decision.Reason
+		switch {
+		case decision.IsDir:
+			marker = "📁"
+			skippedDirs++
+		case decision.Included:
+			marker = "✅"
+			included++
+			if info, err := runConfig.Fs.Stat(decision.Path); err == nil && info.Size() > config.MaxFileSize {
+				marker = "⚠️"
+				reason = fmt.Sprintf("too large, only a placeholder is dumped (%d bytes, --max-filesize is %d)", info.Size(), config.MaxFileSize)
+			}
+		default:
+			excluded++
+		}
+		output.WriteString(fmt.Sprintf("%s %-*s  %s\n", marker, pathWidth, displayPath(decision), reason))
+	}
+
+	output.WriteString(boldCyan(fmt.Sprintf(
+		"\n📚 %d files would be dumped, %d files left out, %d directories skipped\n",
+		included, excluded, skippedDirs,
+	)))
+	fmt.Fprint(runConfig.Output(), output.String())
+}
+
+// mergeDecisions keeps one decision per path, sorted like the summary.
+// A file can be seen more than once, e.g. when it is both inside a
+// walked directory and named by a config include; it is dumped if any
+// of those included it.
+func mergeDecisions(decisions []Decision) []Decision {
+	byPath := make(map[string]Decision)
+	for _, decision := range decisions {
+		existing, seen := byPath[decision.Path]
+		if !seen || (decision.Included && !existing.Included) {
+			byPath[decision.Path] = decision
+		}
+	}
+
+	files := make([]FileInfo, 0, len(byPath))
+	for path := range byPath {
+		files = append(files, FileInfo{Path: path})
+	}
+	merged := make([]Decision, 0, len(byPath))
+	for _, file := range SortFileList(files) {
+		merged = append(merged, byPath[file.Path])
+	}
+	return merged
+}
+
+func displayPath(decision Decision) string {
+	if decision.IsDir {
+		return decision.Path + "/"
+	}
+	return decision.Path
+}
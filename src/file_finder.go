@@ -3,6 +3,7 @@ package src
 import (
 	"fmt"
 	"github.com/spf13/afero"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -12,17 +13,55 @@ type FileFinder struct {
 	Config        Config
 	IgnoreManager *IgnoreManager
 	Fs            afero.Fs
+	// Console is where skipped directories and errors are reported
+	Console io.Writer
+	// RecordDecisions makes the finder keep a Decision for every file
+	// and skipped directory, for the dry run
+	RecordDecisions bool
+	Decisions       []Decision
+	includeGlobs    []globPattern
+	excludeGlobs    []globPattern
 }
 
-func NewFileFinder(config Config, fs afero.Fs) *FileFinder {
+// Decision records why a file was included in the dump or left out,
+// or why a directory was not walked
+type Decision struct {
+	Path     string
+	IsDir    bool
+	Included bool
+	Reason   string
+	// OutOfScope is set for files git selected outside the paths on
+	// the command line, which no filter left out
+	OutOfScope bool
+}
+
+func NewFileFinder(config Config, fs afero.Fs, console io.Writer) *FileFinder {
 	im, err := NewIgnoreManager(fs, config.IncludeIgnored, config.SkipDirs)
 	if err != nil {
-		fmt.Printf(boldRed("❌ Error initializing IgnoreManager: %v\n"), err)
+		fmt.Fprintf(console, boldRed("❌ Error initializing IgnoreManager: %v\n"), err)
+	}
+	ff := &FileFinder{Config: config, Fs: fs, Console: console, IgnoreManager: im}
+
+	for _, pattern := range config.GlobPatterns {
+		glob, err := parseGlobPattern(pattern)
+		if err != nil {
+			fmt.Fprintf(console, boldRed("❌ Error matching glob pattern %s: %v\n"), pattern, err)
+			continue
+		}
+		if glob.exclude {
+			ff.excludeGlobs = append(ff.excludeGlobs, glob)
+		} else {
+			ff.includeGlobs = append(ff.includeGlobs, glob)
+		}
 	}
-	return &FileFinder{Config: config, Fs: fs, IgnoreManager: im}
+	return ff
 }
 
-func (ff *FileFinder) DiscoverFiles() []string {
+func (ff *FileFinder) DiscoverFiles() ([]string, error) {
+	if ff.Config.UsesGit() {
+		return ff.discoverGitFiles()
+	}
+
 	// Use a map to track unique files
 	uniqueFiles := make(map[string]bool)
 
@@ -41,21 +80,124 @@ func (ff *FileFinder) DiscoverFiles() []string {
 		}
 	}
 
-	// Convert map keys to slice
-	result := make([]string, 0, len(uniqueFiles))
-	for file := range uniqueFiles {
-		result = append(result, file)
+	for _, file := range ff.findIncludeGlobMatches() {
+		uniqueFiles[file] = true
+	}
+
+	return mapKeys(uniqueFiles), nil
+}
+
+// findIncludeGlobMatches returns the files matching the glob patterns
+// in the config file include list
+func (ff *FileFinder) findIncludeGlobMatches() []string {
+	var matchingFiles []string
+	for _, pattern := range ff.Config.IncludeGlobs {
+		glob, err := parseGlobPattern(pattern)
+		if err != nil {
+			fmt.Fprintf(ff.Console, boldRed("❌ Error matching glob pattern %s: %v\n"), pattern, err)
+			continue
+		}
+		if exists, _ := afero.DirExists(ff.Fs, glob.baseDir()); !exists {
+			continue
+		}
+		for _, file := range ff.findMatchingFilesInDir(glob.baseDir()) {
+			if glob.matches(file) {
+				matchingFiles = append(matchingFiles, file)
+			}
+		}
+	}
+	return matchingFiles
+}
+
+// discoverGitFiles takes the files selected by git, keeps the ones inside
+// the paths given on the command line and adds the config file includes
+func (ff *FileFinder) discoverGitFiles() ([]string, error) {
+	gitFiles, err := ListGitFiles(ff.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	uniqueFiles := make(map[string]bool)
+	scope := ff.gitScope()
+	for _, file := range gitFiles {
+		if exists, _ := afero.Exists(ff.Fs, file); !exists {
+			continue
+		}
+		if !ff.inScope(file, scope) {
+			if ff.RecordDecisions {
+				ff.Decisions = append(ff.Decisions, Decision{Path: file, Reason: "outside the paths on the command line", OutOfScope: true})
+			}
+			continue
+		}
+		if ff.shouldProcessFile(file) {
+			uniqueFiles[file] = true
+		}
+	}
+
+	for _, includePath := range ff.Config.PriorityPaths {
+		if isDir, _ := afero.IsDir(ff.Fs, includePath); isDir {
+			for _, file := range ff.findMatchingFilesInDir(includePath) {
+				uniqueFiles[file] = true
+			}
+		} else if ff.shouldProcessFile(includePath) {
+			uniqueFiles[includePath] = true
+		}
+	}
+	for _, file := range ff.findIncludeGlobMatches() {
+		uniqueFiles[file] = true
+	}
+
+	return mapKeys(uniqueFiles), nil
+}
+
+// gitScope returns the paths given on the command line, leaving out
+// the ones added by the config file
+func (ff *FileFinder) gitScope() []string {
+	var scope []string
+	for _, path := range append(ff.Config.Directories, ff.Config.SpecificFiles...) {
+		if !contains(ff.Config.PriorityPaths, path) {
+			scope = append(scope, path)
+		}
+	}
+	return scope
+}
+
+func (ff *FileFinder) inScope(file string, scope []string) bool {
+	if len(scope) == 0 {
+		return true
+	}
+	for _, path := range scope {
+		if path == "." || file == path || ff.isSubdirectory(file, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func mapKeys(set map[string]bool) []string {
+	result := make([]string, 0, len(set))
+	for key := range set {
+		result = append(result, key)
 	}
 	return result
 }
 
+func contains(slice []string, item string) bool {
+	for _, s := range slice {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
+
 func (ff *FileFinder) findMatchingFilesInDir(rootDir string) []string {
 	var matchingFiles []string
 
 	afero.Walk(ff.Fs, filepath.Clean(rootDir), func(path string, info os.FileInfo, err error) error {
 		path = NormalizePath(path)
 		if err != nil {
-			PrintError("accessing path", path, err)
+			PrintError(ff.Console, "accessing path", path, err)
 			return nil
 		}
 
@@ -77,47 +219,110 @@ func (ff *FileFinder) findMatchingFilesInDir(rootDir string) []string {
 }
 
 func (ff *FileFinder) shouldSkipDirectory(path string) bool {
-	if ff.IgnoreManager.ShouldIgnore(path) {
-		fmt.Printf("Skipping ignored directory: %s\n", path)
-		return true
+	reason, ignored := ff.explainDirectory(path)
+	if reason == "" {
+		return false
+	}
+	if ff.RecordDecisions {
+		ff.Decisions = append(ff.Decisions, Decision{Path: path, IsDir: true, Reason: reason})
+	} else if ignored {
+		fmt.Fprintf(ff.Console, "Skipping ignored directory: %s\n", path)
+	} else {
+		fmt.Fprintf(ff.Console, "Skipping directory: %s\n", path)
+	}
+	return true
+}
+
+// explainDirectory returns why the walk should not enter path, or ""
+// when it should, and whether the reason came from the ignore files
+func (ff *FileFinder) explainDirectory(path string) (string, bool) {
+	if reason := ff.IgnoreManager.Explain(path); reason != "" {
+		if contains(ff.Config.SkipDirs, path) {
+			reason = ff.describeSkipDir(path)
+		}
+		return reason, true
 	}
 	for _, skipDir := range ff.Config.SkipDirs {
 		if ff.isSubdirectory(path, skipDir) {
-			fmt.Printf("Skipping directory: %s\n", path)
-			return true
+			return ff.describeSkipDir(skipDir), false
 		}
 	}
-	return false
+	for _, glob := range ff.excludeGlobs {
+		if path != "." && glob.matches(path) {
+			return ff.describeExcludeGlob(glob), false
+		}
+	}
+	return "", false
 }
 
 func (ff *FileFinder) isSubdirectory(path, parentDir string) bool {
-	normalizedParent := filepath.Clean(parentDir)
-	return path == normalizedParent || strings.HasPrefix(path, normalizedParent+string(os.PathSeparator))
+	return isWithin(NormalizePath(path), NormalizePath(parentDir))
 }
 
 func (ff *FileFinder) shouldProcessFile(filePath string) bool {
-	if ff.IgnoreManager.ShouldIgnore(filePath) {
-		return false
+	include, reason := ff.explainFile(filePath)
+	if ff.RecordDecisions {
+		ff.Decisions = append(ff.Decisions, Decision{Path: filePath, Included: include, Reason: reason})
+	}
+	return include
+}
+
+// explainFile decides whether a file is dumped and names the rule
+// that decided it
+func (ff *FileFinder) explainFile(filePath string) (bool, string) {
+	// Files selected by git never pass through the directory walk,
+	// so a skipped directory has to be checked against each file
+	for _, skipDir := range ff.Config.SkipDirs {
+		if ff.isSubdirectory(filePath, skipDir) {
+			return false, ff.describeSkipDir(skipDir)
+		}
+	}
+	if reason := ff.IgnoreManager.Explain(filePath); reason != "" {
+		return false, reason
+	}
+
+	for _, glob := range ff.excludeGlobs {
+		if glob.matchesPathOrParent(filePath) {
+			return false, ff.describeExcludeGlob(glob)
+		}
 	}
 
 	// Check glob patterns first if they exist
-	if len(ff.Config.GlobPatterns) > 0 {
-		filename := filepath.Base(filePath)
-		for _, pattern := range ff.Config.GlobPatterns {
-			matched, err := filepath.Match(pattern, filename)
-			if err != nil {
-				fmt.Printf(boldRed("❌ Error matching glob pattern %s: %v\n"), pattern, err)
-				continue
-			}
-			if matched {
-				return true
+	if len(ff.includeGlobs) > 0 {
+		var patterns []string
+		for _, glob := range ff.includeGlobs {
+			if glob.matches(filePath) {
+				return true, fmt.Sprintf("matches --glob %s", glob.Raw)
 			}
+			patterns = append(patterns, glob.Raw)
 		}
-		return false
+		return false, fmt.Sprintf("does not match --glob %s", strings.Join(patterns, ", "))
 	}
 
-	// If no glob patterns, fall back to extension matching
-	return ff.matchesExtensions(filepath.Base(filePath))
+	// If there are only exclusion globs, fall back to extension matching
+	if !ff.matchesExtensions(filepath.Base(filePath)) {
+		return false, fmt.Sprintf("extension not in --extension %s", strings.Join(ff.Config.Extensions, ","))
+	}
+	if len(ff.Config.Extensions) > 0 {
+		return true, fmt.Sprintf("matches --extension %s", strings.Join(ff.Config.Extensions, ","))
+	}
+	return true, "no filter excludes it"
+}
+
+// describeSkipDir tells apart the directories from --skip and the
+// ones from the config file ignore list, which end up in the same list
+func (ff *FileFinder) describeSkipDir(skipDir string) string {
+	if contains(ff.Config.IgnorePaths, skipDir) {
+		return fmt.Sprintf("config ignore entry %s", skipDir)
+	}
+	return fmt.Sprintf("--skip %s", skipDir)
+}
+
+func (ff *FileFinder) describeExcludeGlob(glob globPattern) string {
+	if contains(ff.Config.IgnorePaths, strings.TrimPrefix(glob.Raw, "!")) {
+		return fmt.Sprintf("config ignore entry %s", strings.TrimPrefix(glob.Raw, "!"))
+	}
+	return fmt.Sprintf("excluded by --glob %s", glob.Raw)
 }
 
 func (ff *FileFinder) matchesExtensions(filename string) bool {
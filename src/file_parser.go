@@ -2,59 +2,82 @@ package src
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"github.com/spf13/afero"
 	"io"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 )
 
-const FilesPerGoroutine = 1
-
 type FileProcessor struct {
 	Fs     afero.Fs
 	Config Config
+	// Console is where files that cannot be processed are reported
+	Console io.Writer
+	// DiffBase is the commit diffs are taken against in diff mode
+	DiffBase string
+	// Workers is how many files are processed at once. In diff mode each
+	// of them runs git, so this also bounds the git processes.
+	Workers int
 }
 
-func NewFileProcessor(fs afero.Fs, config Config) *FileProcessor {
-	return &FileProcessor{Fs: fs, Config: config}
+func NewFileProcessor(fs afero.Fs, config Config, console io.Writer) *FileProcessor {
+	return &FileProcessor{Fs: fs, Config: config, Console: console, Workers: runtime.NumCPU()}
 }
 
-func (fp *FileProcessor) ProcessFiles(files []string) []FileInfo {
-	var wg sync.WaitGroup
+// ProcessFiles reads the files. Files that cannot be read are reported
+// and left out, but a selector that matches nothing in its file is a
+// mistake in the command, so it is returned as an error instead of
+// dumping everything else.
+func (fp *FileProcessor) ProcessFiles(files []string) ([]FileInfo, error) {
+	paths := make(chan string)
 	fileInfoChan := make(chan FileInfo, len(files))
+	var selectorErrs []error
+	var selectorErrsMu sync.Mutex
 
-	for i := 0; i < len(files); i += FilesPerGoroutine {
-		end := i + FilesPerGoroutine
-		if end > len(files) {
-			end = len(files)
-		}
-
+	var wg sync.WaitGroup
+	for i := 0; i < max(1, min(fp.Workers, len(files))); i++ {
 		wg.Add(1)
-		go func(chunk []string) {
+		go func() {
 			defer wg.Done()
-			for _, file := range chunk {
+			for file := range paths {
 				fileInfo, err := fp.processFile(NormalizePath(file))
+				var selectorErr ErrInvalidSelector
+				if errors.As(err, &selectorErr) {
+					selectorErrsMu.Lock()
+					selectorErrs = append(selectorErrs, selectorErr)
+					selectorErrsMu.Unlock()
+					continue
+				}
 				if err != nil {
-					PrintError("processing", file, err)
+					PrintError(fp.Console, "processing", file, err)
 					continue
 				}
 				fileInfoChan <- fileInfo
 			}
-		}(files[i:end])
+		}()
 	}
 
-	go func() {
-		wg.Wait()
-		close(fileInfoChan)
-	}()
+	for _, file := range files {
+		paths <- file
+	}
+	close(paths)
+	wg.Wait()
+	close(fileInfoChan)
 
 	var processedFiles []FileInfo
 	for fileInfo := range fileInfoChan {
 		processedFiles = append(processedFiles, fileInfo)
 	}
 
-	return processedFiles
+	sort.Slice(selectorErrs, func(i, j int) bool {
+		return selectorErrs[i].Error() < selectorErrs[j].Error()
+	})
+	return processedFiles, errors.Join(selectorErrs...)
 }
 
 func (fp *FileProcessor) processFile(path string) (FileInfo, error) {
@@ -68,20 +91,34 @@ func (fp *FileProcessor) processFile(path string) (FileInfo, error) {
 	if err != nil {
 		return FileInfo{}, fmt.Errorf("getting file info: %w", err)
 	}
+	fileInfo := FileInfo{Path: path, Size: info.Size(), ModTime: info.ModTime()}
+
 	if info.Size() == 0 {
-		return FileInfo{Path: path, Contents: "<EMPTY FILE>", Status: StatusParsed}, nil
+		fileInfo.Language = DetectLanguage(path, "")
+		return fileInfo.with(StatusParsed, "<EMPTY FILE>"), nil
 	}
 
 	if info.Size() > fp.Config.MaxFileSize {
-		return FileInfo{Status: StatusSkippedTooLarge, Path: path, Contents: fmt.Sprintf("<FILE TOO LARGE: %d bytes>", info.Size())}, nil
+		return fileInfo.with(StatusSkippedTooLarge, fmt.Sprintf("<FILE TOO LARGE: %d bytes>", info.Size())), nil
 	}
 
-	isBinary, err := fp.fileIsBinary(file)
+	isBinary, err := fileIsBinary(file)
 	if err != nil {
 		return FileInfo{}, fmt.Errorf("checking if file is binary: %w", err)
 	}
 	if isBinary {
-		return FileInfo{Status: StatusSkippedBinary, Path: path, Contents: "<BINARY SKIPPED>"}, nil
+		return fileInfo.with(StatusSkippedBinary, "<BINARY SKIPPED>"), nil
+	}
+
+	if fp.Config.Diff != DiffModeNone {
+		fileInfo.Diff, err = GitDiff(fp.Config, fp.DiffBase, path)
+		if err != nil {
+			return FileInfo{}, fmt.Errorf("getting diff: %w", err)
+		}
+		// Files without a diff, like config includes, are dumped whole
+		if fileInfo.Diff != "" && fp.Config.Diff == DiffModeOnly {
+			return fileInfo.with(StatusParsed, ""), nil
+		}
 	}
 
 	var contents strings.Builder
@@ -94,15 +131,43 @@ func (fp *FileProcessor) processFile(path string) (FileInfo, error) {
 
 	if err := scanner.Err(); err != nil {
 		if err == bufio.ErrTooLong {
-			return FileInfo{Path: path, Contents: "<FILE EXCEEDS BUFFER SIZE>"}, nil
+			return fileInfo.with("", "<FILE EXCEEDS BUFFER SIZE>"), nil
 		}
 		return FileInfo{}, fmt.Errorf("scanning file: %w", err)
 	}
 
-	return FileInfo{Status: StatusParsed, Path: path, Contents: contents.String()}, nil
+	fileInfo.Language = DetectLanguage(path, contents.String())
+	if selectors := fp.Config.Selections[path]; len(selectors) > 0 {
+		regions, err := SelectRegions(path, contents.String(), selectors)
+		if err != nil {
+			return FileInfo{}, err
+		}
+		fileInfo.Region = DescribeRegions(regions, strings.Count(contents.String(), "\n"))
+		return fileInfo.with(StatusParsed, ExtractRegions(contents.String(), regions, fp.Config.LineNumbers)), nil
+	}
+	if fp.Config.LineNumbers {
+		text := contents.String()
+		return fileInfo.with(StatusParsed, NumberLines(text, 1, GutterWidth(len(splitLines(text))))), nil
+	}
+	return fileInfo.with(StatusParsed, contents.String()), nil
+}
+
+// NumberLines prefixes each line with its number, counting from first,
+// in a gutter of the given width so the code stays aligned
+func NumberLines(contents string, first, width int) string {
+	var numbered strings.Builder
+	for i, line := range splitLines(contents) {
+		fmt.Fprintf(&numbered, "%*d | %s", width, first+i, line)
+	}
+	return numbered.String()
+}
+
+// GutterWidth is the width of the widest line number up to last
+func GutterWidth(last int) int {
+	return len(strconv.Itoa(last))
 }
 
-func (fp *FileProcessor) fileIsBinary(file afero.File) (bool, error) {
+func fileIsBinary(file afero.File) (bool, error) {
 	buffer := make([]byte, 512)
 	bytesRead, err := file.Read(buffer)
 	if err != nil && err != io.EOF {
@@ -0,0 +1,182 @@
+package src
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const DefaultFormat = "plain"
+
+// Formatter wraps each file in an envelope that tells a model
+// where one file ends and the next one begins.
+type Formatter interface {
+	FormatFile(fileInfo FileInfo) string
+	FormatDiff(fileInfo FileInfo) string
+	// FormatTree wraps the directory tree shown before the files
+	FormatTree(tree string) string
+}
+
+var formatters = map[string]func() Formatter{
+	"plain":    func() Formatter { return &PlainFormatter{} },
+	"xml":      func() Formatter { return &XMLFormatter{} },
+	"markdown": func() Formatter { return &MarkdownFormatter{} },
+}
+
+// ErrInvalidFormat is a custom error type for unknown output formats
+type ErrInvalidFormat struct {
+	Value string
+}
+
+func (e ErrInvalidFormat) Error() string {
+	return fmt.Sprintf("invalid format: %s (available: %s)", e.Value, strings.Join(FormatNames(), ", "))
+}
+
+func NewFormatter(name string) (Formatter, error) {
+	if name == "" {
+		name = DefaultFormat
+	}
+	newFormatter, ok := formatters[name]
+	if !ok {
+		return nil, ErrInvalidFormat{Value: name}
+	}
+	return newFormatter(), nil
+}
+
+func FormatNames() []string {
+	names := make([]string, 0, len(formatters))
+	for name := range formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FormatEntry formats a file's contents, its diff, or the contents
+// followed by the diff, depending on what the processor filled in
+func FormatEntry(formatter Formatter, fileInfo FileInfo) string {
+	var entry string
+	if fileInfo.Diff == "" || fileInfo.Contents != "" {
+		entry = formatter.FormatFile(fileInfo)
+	}
+	if fileInfo.Diff != "" {
+		entry += formatter.FormatDiff(fileInfo)
+	}
+	return entry
+}
+
+// PlainFormatter is the original START FILE / END FILE envelope
+type PlainFormatter struct{}
+
+func (f *PlainFormatter) FormatFile(fileInfo FileInfo) string {
+	if fileInfo.Region != "" {
+		return fmt.Sprintf("START FILE: %s (%s)\n%s\nEND FILE: %s\n\n", fileInfo.Path, fileInfo.Region, fileInfo.Contents, fileInfo.Path)
+	}
+	return FormatFileContent(fileInfo.Path, fileInfo.Contents)
+}
+
+func (f *PlainFormatter) FormatDiff(fileInfo FileInfo) string {
+	return FormatDiffContent(fileInfo.Path, fileInfo.Diff)
+}
+
+func (f *PlainFormatter) FormatTree(tree string) string {
+	return fmt.Sprintf("START TREE\n%sEND TREE\n\n", withTrailingNewline(tree))
+}
+
+// XMLFormatter wraps each file in a <document> tag
+type XMLFormatter struct{}
+
+var xmlAttributeEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	`"`, "&quot;",
+)
+
+func (f *XMLFormatter) FormatFile(fileInfo FileInfo) string {
+	region := ""
+	if fileInfo.Region != "" {
+		region = fmt.Sprintf(" region=\"%s\"", xmlAttributeEscaper.Replace(fileInfo.Region))
+	}
+	return fmt.Sprintf(
+		"<document path=\"%s\"%s>\n<source>\n%s</source>\n</document>\n\n",
+		xmlAttributeEscaper.Replace(fileInfo.Path),
+		region,
+		withTrailingNewline(fileInfo.Contents),
+	)
+}
+
+func (f *XMLFormatter) FormatDiff(fileInfo FileInfo) string {
+	return fmt.Sprintf(
+		"<diff path=\"%s\">\n%s</diff>\n\n",
+		xmlAttributeEscaper.Replace(fileInfo.Path),
+		withTrailingNewline(fileInfo.Diff),
+	)
+}
+
+func (f *XMLFormatter) FormatTree(tree string) string {
+	return fmt.Sprintf("<tree>\n%s</tree>\n\n", withTrailingNewline(tree))
+}
+
+// MarkdownFormatter puts each file in a fenced code block under a heading,
+// tagging the block with the detected language for syntax highlighting
+type MarkdownFormatter struct{}
+
+func (f *MarkdownFormatter) FormatFile(fileInfo FileInfo) string {
+	fence := codeFence(fileInfo.Contents)
+	language := ""
+	if fileInfo.Status == StatusParsed {
+		language = fileInfo.Language
+	}
+	heading := fileInfo.Path
+	if fileInfo.Region != "" {
+		heading += " (" + fileInfo.Region + ")"
+	}
+	return fmt.Sprintf(
+		"### %s\n\n%s%s\n%s%s\n\n",
+		heading,
+		fence,
+		language,
+		withTrailingNewline(fileInfo.Contents),
+		fence,
+	)
+}
+
+func (f *MarkdownFormatter) FormatDiff(fileInfo FileInfo) string {
+	fence := codeFence(fileInfo.Diff)
+	return fmt.Sprintf(
+		"### %s (diff)\n\n%sdiff\n%s%s\n\n",
+		fileInfo.Path,
+		fence,
+		withTrailingNewline(fileInfo.Diff),
+		fence,
+	)
+}
+
+func (f *MarkdownFormatter) FormatTree(tree string) string {
+	return fmt.Sprintf("### Project tree\n\n```text\n%s```\n\n", withTrailingNewline(tree))
+}
+
+// codeFence returns a backtick fence longer than any backtick run in
+// the contents, so files containing markdown do not end the block early.
+func codeFence(contents string) string {
+	longest, current := 0, 0
+	for _, r := range contents {
+		if r == '`' {
+			current++
+			if current > longest {
+				longest = current
+			}
+		} else {
+			current = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+func withTrailingNewline(contents string) string {
+	if strings.HasSuffix(contents, "\n") {
+		return contents
+	}
+	return contents + "\n"
+}
@@ -0,0 +1,144 @@
+package src
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// UsesGit reports whether files should come from git instead of a directory walk
+func (c *Config) UsesGit() bool {
+	return c.GitChanged || c.GitStaged || c.GitSince != "" || c.Diff != DiffModeNone
+}
+
+// DiffRef is the ref diffs are taken against: --diff-base when given,
+// then the --git-since ref, then HEAD
+func (c *Config) DiffRef() string {
+	if c.DiffBase != "" {
+		return c.DiffBase
+	}
+	if c.GitSince != "" {
+		return c.GitSince
+	}
+	return "HEAD"
+}
+
+// ListGitFiles returns the paths selected by the git options, relative to
+// the working directory. Deleted files are left out, as there is nothing
+// left to dump.
+func ListGitFiles(config Config) ([]string, error) {
+	uniqueFiles := make(map[string]bool)
+	var queries [][]string
+
+	since := config.GitSince
+	if !config.GitChanged && !config.GitStaged && since == "" {
+		// Diff mode on its own selects the files that have a diff
+		since = config.DiffRef()
+	}
+
+	if config.GitChanged {
+		queries = append(queries, []string{"diff", "--name-only", "-z", "--relative", "--diff-filter=d", "HEAD"})
+	}
+	if config.GitStaged {
+		queries = append(queries, []string{"diff", "--name-only", "-z", "--relative", "--diff-filter=d", "--cached"})
+	}
+	if since != "" {
+		mergeBase, err := MergeBase(since)
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, []string{"diff", "--name-only", "-z", "--relative", "--diff-filter=d", mergeBase})
+	}
+	if config.GitChanged || since != "" {
+		// New files the user has not added yet
+		queries = append(queries, []string{"ls-files", "-z", "--others", "--exclude-standard"})
+	}
+
+	for _, query := range queries {
+		files, err := runGit(query...)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			uniqueFiles[NormalizePath(file)] = true
+		}
+	}
+
+	result := make([]string, 0, len(uniqueFiles))
+	for file := range uniqueFiles {
+		result = append(result, file)
+	}
+	return result, nil
+}
+
+// MergeBase returns the commit where HEAD split from ref, so changes made
+// on ref after the split do not show up as changes on this branch
+func MergeBase(ref string) (string, error) {
+	mergeBase, err := runGit("merge-base", ref, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	if len(mergeBase) == 0 {
+		return "", fmt.Errorf("no common ancestor between %s and HEAD", ref)
+	}
+	return mergeBase[0], nil
+}
+
+// GitDiff returns the unified diff of path against base, including the
+// working tree. Untracked files are diffed against an empty file. Staged
+// changes are diffed from the index when only --git-staged was asked for.
+func GitDiff(config Config, base, path string) (string, error) {
+	args := []string{"diff", base, "--", path}
+	if config.GitStaged && !config.GitChanged && config.GitSince == "" && config.DiffBase == "" {
+		args = []string{"diff", "--cached", base, "--", path}
+	}
+	diff, err := gitOutput(args...)
+	if err != nil || diff != "" {
+		return diff, err
+	}
+
+	untracked, err := runGit("ls-files", "-z", "--others", "--exclude-standard", "--", path)
+	if err != nil || len(untracked) == 0 {
+		return "", err
+	}
+	// git diff --no-index exits with 1 when the files differ
+	args = []string{"diff", "--no-index", "--", "/dev/null", strings.TrimPrefix(path, "./")}
+	output, err := ExecCommand("git", args...).Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return string(output), nil
+	}
+	if err != nil {
+		return "", gitError(args, err)
+	}
+	return string(output), nil
+}
+
+func runGit(args ...string) ([]string, error) {
+	output, err := gitOutput(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Paths are NUL separated with -z, so they are never quoted
+	return strings.FieldsFunc(output, func(r rune) bool {
+		return r == 0 || r == '\n'
+	}), nil
+}
+
+func gitOutput(args ...string) (string, error) {
+	output, err := ExecCommand("git", args...).Output()
+	if err != nil {
+		return "", gitError(args, err)
+	}
+	return string(output), nil
+}
+
+func gitError(args []string, err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("git %s: %s", strings.Join(args, " "), strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+}
@@ -0,0 +1,147 @@
+package src
+
+import (
+	"bufio"
+	"path"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// ignorePattern is a single line of a gitignore style file
+type ignorePattern struct {
+	Raw      string
+	Source   string
+	Line     int
+	segments []string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// ignoreFile holds the patterns of one ignore file. Patterns only
+// apply to paths below BaseDir, which is relative to the repository root.
+type ignoreFile struct {
+	BaseDir  string
+	patterns []ignorePattern
+}
+
+func parseIgnoreFile(fs afero.Fs, filePath, baseDir string) *ignoreFile {
+	file, err := fs.Open(filePath)
+	if err != nil {
+		return nil // Ignore errors, as the file might not exist
+	}
+	defer file.Close()
+
+	ignore := &ignoreFile{BaseDir: baseDir}
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		if pattern, ok := parseIgnorePattern(scanner.Text()); ok {
+			pattern.Source = filePath
+			pattern.Line = lineNumber
+			ignore.patterns = append(ignore.patterns, pattern)
+		}
+	}
+	return ignore
+}
+
+func parseIgnorePattern(line string) (ignorePattern, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	line = trimUnescapedTrailingSpaces(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignorePattern{}, false
+	}
+
+	pattern := ignorePattern{Raw: line}
+	if strings.HasPrefix(line, "!") {
+		pattern.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		pattern.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	// A slash at the beginning or in the middle anchors the pattern
+	// to the directory of the ignore file
+	if strings.Contains(line, "/") {
+		pattern.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return ignorePattern{}, false
+	}
+
+	// gitignore writes negated character classes as [!...]
+	line = strings.ReplaceAll(line, "[!", "[^")
+	pattern.segments = strings.Split(line, "/")
+	return pattern, true
+}
+
+func trimUnescapedTrailingSpaces(line string) string {
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+	return line
+}
+
+// matches reports whether relPath, relative to the ignore file's
+// directory, is matched by this pattern
+func (p ignorePattern) matches(relPath string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	if !p.anchored {
+		return matchSegments(p.segments, []string{path.Base(relPath)})
+	}
+	return matchSegments(p.segments, strings.Split(relPath, "/"))
+}
+
+// matchSegments matches slash separated pattern segments against path
+// segments, where a "**" segment matches zero or more directories
+func matchSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			// A trailing "/**" matches everything inside, but not the directory itself
+			if len(rest) == 0 {
+				return len(segments) > 0
+			}
+			for i := 0; i <= len(segments); i++ {
+				if matchSegments(rest, segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(segments) == 0 {
+			return false
+		}
+		if matched, err := path.Match(pattern[0], segments[0]); err != nil || !matched {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
+
+// match returns the last pattern in the file matching the path,
+// or nil if the path is outside BaseDir or nothing matches
+func (f *ignoreFile) match(rootRelPath string, isDir bool) *ignorePattern {
+	relPath := rootRelPath
+	if f.BaseDir != "" {
+		if !strings.HasPrefix(rootRelPath, f.BaseDir+"/") {
+			return nil
+		}
+		relPath = strings.TrimPrefix(rootRelPath, f.BaseDir+"/")
+	}
+
+	for i := len(f.patterns) - 1; i >= 0; i-- {
+		if f.patterns[i].matches(relPath, isDir) {
+			return &f.patterns[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package src
+
+import (
+	"path"
+	"strings"
+)
+
+// globPattern is a --glob pattern. Patterns without a slash match the
+// file name, like "*_test.go". Patterns with a slash match the path
+// relative to the working directory, where "**" matches any number of
+// directories, like "src/**/handlers/*.go". A leading "./" anchors a
+// pattern to the working directory, and a leading "!" turns the pattern
+// into an exclusion.
+type globPattern struct {
+	Raw      string
+	segments []string
+	exclude  bool
+	basename bool
+}
+
+func parseGlobPattern(pattern string) (globPattern, error) {
+	glob := globPattern{Raw: pattern}
+	if strings.HasPrefix(pattern, "!") {
+		glob.exclude = true
+		pattern = pattern[1:]
+	}
+	glob.basename = !strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(strings.ReplaceAll(pattern, "[!", "[^"), "./")
+	glob.segments = strings.Split(strings.Trim(pattern, "/"), "/")
+
+	for _, segment := range glob.segments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return glob, err
+		}
+	}
+	return glob, nil
+}
+
+func (g globPattern) matches(filePath string) bool {
+	if g.basename {
+		matched, _ := path.Match(g.segments[0], path.Base(filePath))
+		return matched
+	}
+	return matchSegments(g.segments, globSegments(filePath))
+}
+
+// matchesPathOrParent reports whether the pattern matches the path or
+// one of its directories, so excluding a directory excludes its contents
+func (g globPattern) matchesPathOrParent(filePath string) bool {
+	segments := globSegments(filePath)
+	for i := len(segments); i > 0; i-- {
+		if g.matches(strings.Join(segments[:i], "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// baseDir is the directory part of the pattern before its first
+// wildcard, which is the only place matching files can be
+func (g globPattern) baseDir() string {
+	if g.basename {
+		return "."
+	}
+	var base []string
+	for _, segment := range g.segments[:len(g.segments)-1] {
+		if strings.ContainsAny(segment, "*?[\\") {
+			break
+		}
+		base = append(base, segment)
+	}
+	if len(base) == 0 {
+		return "."
+	}
+	return NormalizePath(strings.Join(base, "/"))
+}
+
+// IsGlob reports whether a config file entry is a glob pattern
+// rather than a plain path
+func IsGlob(entry string) bool {
+	return strings.ContainsAny(entry, "*?[")
+}
+
+func globSegments(filePath string) []string {
+	filePath = strings.TrimPrefix(path.Clean(filePath), "./")
+	if filePath == "." {
+		return nil
+	}
+	return strings.Split(filePath, "/")
+}
@@ -1,22 +1,40 @@
 package src
 
 import (
-	"bufio"
-	"github.com/spf13/afero"
+	"fmt"
+	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"strings"
 
-	"github.com/gobwas/glob"
+	"github.com/spf13/afero"
 )
 
 var ExecCommand = exec.Command
 
+// IgnoreManager decides which paths git would ignore. It follows git's
+// rules: the global excludes file, .git/info/exclude and every .gitignore
+// from the repository root down to the path are consulted, later and
+// deeper files take precedence, and within a file the last matching
+// pattern wins.
 type IgnoreManager struct {
 	fs afero.Fs
 
-	ignorePatterns []glob.Glob
-	ignoreDirs     []string
+	// rootFsPath is the repository root relative to the working directory,
+	// cwdFromRoot is the working directory relative to the repository root
+	rootFsPath  string
+	absRoot     string
+	cwdFromRoot string
+
+	// tracked holds the files in the git index and their parent
+	// directories, relative to the repository root. git lists tracked
+	// files even when an ignore pattern matches them.
+	tracked map[string]bool
+
+	baseFiles      []*ignoreFile
+	dirFiles       map[string]*ignoreFile
+	dirDecisions   map[string]*ignorePattern
 	skipPaths      []string
 	includeIgnored bool
 }
@@ -26,6 +44,9 @@ func NewIgnoreManager(fs afero.Fs, includeIgnored bool, skipPaths []string) (*Ig
 		fs:             fs,
 		includeIgnored: includeIgnored,
 		skipPaths:      skipPaths,
+		rootFsPath:     ".",
+		dirFiles:       make(map[string]*ignoreFile),
+		dirDecisions:   make(map[string]*ignorePattern),
 	}
 	err := im.loadIgnorePatterns()
 	if err != nil {
@@ -35,82 +56,245 @@ func NewIgnoreManager(fs afero.Fs, includeIgnored bool, skipPaths []string) (*Ig
 }
 
 func (im *IgnoreManager) loadIgnorePatterns() error {
-	// Add .git to ignore patterns
-	im.ignoreDirs = append(im.ignoreDirs, ".git")
+	if im.findRepositoryRoot() {
+		im.loadTrackedFiles()
+	}
 
 	// Load global gitignore
 	globalGitignorePath, err := getGlobalGitignorePath()
 	if err == nil && globalGitignorePath != "" {
-		im.loadIgnoreFile(globalGitignorePath)
+		im.addBaseFile(globalGitignorePath)
 	}
 
-	// Load project-specific gitignore
-	im.loadIgnoreFile(".gitignore")
+	// Load repository specific excludes that are not committed
+	im.addBaseFile(filepath.Join(im.rootFsPath, ".git", "info", "exclude"))
 
 	return nil
 }
 
+func (im *IgnoreManager) addBaseFile(path string) {
+	if file := parseIgnoreFile(im.fs, path, ""); file != nil {
+		im.baseFiles = append(im.baseFiles, file)
+	}
+}
+
+// findRepositoryRoot walks up from the working directory looking for a
+// .git entry. Without one, the working directory is treated as the root.
+// It reports whether a repository was found.
+func (im *IgnoreManager) findRepositoryRoot() bool {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return false
+	}
+	im.absRoot = cwd
+
+	dir, fsPath := cwd, "."
+	for {
+		if exists, _ := afero.Exists(im.fs, filepath.Join(fsPath, ".git")); exists {
+			rel, _ := filepath.Rel(dir, cwd)
+			im.rootFsPath = fsPath
+			im.absRoot = dir
+			if rel != "." {
+				im.cwdFromRoot = filepath.ToSlash(rel)
+			}
+			return true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir, fsPath = parent, filepath.Join(fsPath, "..")
+	}
+}
+
+// loadTrackedFiles asks git for the files in the index, so the files
+// dump_dir sees are the ones git ls-files --others --cached
+// --exclude-standard lists. It is left empty when git does not agree
+// on where the repository is, e.g. without git installed.
+func (im *IgnoreManager) loadTrackedFiles() {
+	topLevel, err := gitOutput("rev-parse", "--show-toplevel")
+	if err != nil || filepath.Clean(strings.TrimSpace(topLevel)) != filepath.Clean(im.absRoot) {
+		return
+	}
+	files, err := runGit("ls-files", "-z", "--cached", "--full-name", "--", ":/")
+	if err != nil {
+		return
+	}
+
+	im.tracked = make(map[string]bool, len(files))
+	for _, file := range files {
+		for relPath := file; relPath != "."; relPath = path.Dir(relPath) {
+			if im.tracked[relPath] {
+				break
+			}
+			im.tracked[relPath] = true
+		}
+	}
+}
+
 func getGlobalGitignorePath() (string, error) {
 	cmd := ExecCommand("git", "config", "--global", "--get", "core.excludesfile")
 	output, err := cmd.Output()
+	if err == nil {
+		if path := strings.TrimSpace(string(output)); path != "" {
+			return expandHomeDir(path)
+		}
+	}
+
+	// git falls back to $XDG_CONFIG_HOME/git/ignore
+	if configHome := os.Getenv("XDG_CONFIG_HOME"); configHome != "" {
+		return filepath.Join(configHome, "git", "ignore"), nil
+	}
+	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
 	}
-	return strings.TrimSpace(string(output)), nil
+	return filepath.Join(home, ".config", "git", "ignore"), nil
 }
 
-func (im *IgnoreManager) loadIgnoreFile(path string) {
-	file, err := im.fs.Open(path)
+func expandHomeDir(path string) (string, error) {
+	if !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
 	if err != nil {
-		return // Ignore errors, as the file might not exist
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		pattern := strings.TrimSpace(scanner.Text())
-		if pattern != "" && !strings.HasPrefix(pattern, "#") {
-			if strings.HasSuffix(pattern, "/") {
-				// Directory pattern
-				im.ignoreDirs = append(im.ignoreDirs, strings.TrimSuffix(pattern, "/"))
-			} else {
-				// File pattern
-				if g, err := glob.Compile(pattern); err == nil {
-					im.ignorePatterns = append(im.ignorePatterns, g)
-				}
-			}
-		}
-
+		return "", err
 	}
+	return filepath.Join(home, path[2:]), nil
 }
 
 func (im *IgnoreManager) ShouldIgnore(path string) bool {
+	return im.Explain(path) != ""
+}
+
+// Explain returns why path is ignored, naming the ignore file, line and
+// pattern for gitignore rules, or "" when it is not ignored
+func (im *IgnoreManager) Explain(path string) string {
 	if im.includeIgnored {
-		return false
+		return ""
 	}
-	// Check if the path or any of its parent directories should be ignored
-	dir := path
-	for dir != "." && dir != "/" {
-		baseName := filepath.Base(dir)
-		for _, ignoreDir := range im.ignoreDirs {
-			if baseName == ignoreDir {
-				return true
-			}
+
+	for _, skipPath := range im.skipPaths {
+		if isWithin(NormalizePath(path), NormalizePath(skipPath)) {
+			return fmt.Sprintf("skipped path %s", skipPath)
 		}
-		dir = filepath.Dir(dir)
 	}
 
-	for _, pattern := range im.ignorePatterns {
-		if pattern.Match(path) {
+	if isInsideGitDirectory(path) {
+		return "inside .git"
+	}
+
+	relPath, ok := im.rootRelative(path)
+	if !ok || relPath == "" || im.tracked[relPath] {
+		return ""
+	}
+	pattern := im.ignoredBy(relPath, im.isDir(path))
+	if pattern == nil {
+		return ""
+	}
+	return fmt.Sprintf("ignored by %s:%d (%s)", pattern.Source, pattern.Line, pattern.Raw)
+}
+
+func isInsideGitDirectory(path string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(path), "/") {
+		if part == ".git" {
 			return true
 		}
 	}
+	return false
+}
 
-	for _, skipPath := range im.skipPaths {
-		if skipPath == path {
-			return true
+// rootRelative converts a path as given on the command line into a slash
+// separated path relative to the repository root. It returns false for
+// paths outside the repository, which no ignore file applies to.
+func (im *IgnoreManager) rootRelative(filePath string) (string, bool) {
+	var relPath string
+	if filepath.IsAbs(filePath) {
+		if im.absRoot == "" {
+			return "", false
+		}
+		rel, err := filepath.Rel(im.absRoot, filePath)
+		if err != nil {
+			return "", false
 		}
+		relPath = filepath.ToSlash(rel)
+	} else {
+		relPath = path.Join(im.cwdFromRoot, filepath.ToSlash(filePath))
 	}
 
-	return false
+	if relPath == ".." || strings.HasPrefix(relPath, "../") {
+		return "", false
+	}
+	if relPath == "." {
+		return "", true
+	}
+	return relPath, true
+}
+
+func (im *IgnoreManager) isDir(path string) bool {
+	info, err := im.fs.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// ignoredBy returns the pattern excluding relPath, or nil. It checks the
+// parent directories first, as git cannot re-include a file once one of
+// its parent directories is excluded.
+func (im *IgnoreManager) ignoredBy(relPath string, isDir bool) *ignorePattern {
+	parts := strings.Split(relPath, "/")
+	for i := 1; i < len(parts); i++ {
+		if pattern := im.dirIgnoredBy(strings.Join(parts[:i], "/")); pattern != nil {
+			return pattern
+		}
+	}
+	return im.excludedBy(relPath, isDir)
+}
+
+func (im *IgnoreManager) dirIgnoredBy(relDir string) *ignorePattern {
+	if pattern, ok := im.dirDecisions[relDir]; ok {
+		return pattern
+	}
+	pattern := im.excludedBy(relDir, true)
+	im.dirDecisions[relDir] = pattern
+	return pattern
+}
+
+func (im *IgnoreManager) excludedBy(relPath string, isDir bool) *ignorePattern {
+	pattern := im.match(relPath, isDir)
+	if pattern == nil || pattern.negate {
+		return nil
+	}
+	return pattern
+}
+
+// match returns the pattern deciding whether relPath is ignored, searching
+// from the highest precedence ignore file to the lowest
+func (im *IgnoreManager) match(relPath string, isDir bool) *ignorePattern {
+	dirs := []string{""}
+	parts := strings.Split(relPath, "/")
+	for i := 1; i < len(parts); i++ {
+		dirs = append(dirs, strings.Join(parts[:i], "/"))
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if file := im.gitignoreIn(dirs[i]); file != nil {
+			if pattern := file.match(relPath, isDir); pattern != nil {
+				return pattern
+			}
+		}
+	}
+	for i := len(im.baseFiles) - 1; i >= 0; i-- {
+		if pattern := im.baseFiles[i].match(relPath, isDir); pattern != nil {
+			return pattern
+		}
+	}
+	return nil
+}
+
+func (im *IgnoreManager) gitignoreIn(relDir string) *ignoreFile {
+	if file, ok := im.dirFiles[relDir]; ok {
+		return file
+	}
+	file := parseIgnoreFile(im.fs, filepath.Join(im.rootFsPath, relDir, ".gitignore"), relDir)
+	im.dirFiles[relDir] = file
+	return file
 }
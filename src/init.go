@@ -0,0 +1,277 @@
+package src
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// vendorDirs are directories of dependencies and build output that are
+// rarely worth showing to a model
+var vendorDirs = map[string]string{
+	"node_modules":  "installed packages",
+	"vendor":        "vendored dependencies",
+	".venv":         "Python virtual environment",
+	"venv":          "Python virtual environment",
+	"__pycache__":   "Python bytecode",
+	".tox":          "tox environments",
+	"dist":          "build output",
+	"build":         "build output",
+	"target":        "build output",
+	".next":         "Next.js build output",
+	"coverage":      "test coverage reports",
+	".terraform":    "Terraform providers",
+	".gradle":       "Gradle caches",
+	".mypy_cache":   "mypy cache",
+	".pytest_cache": "pytest cache",
+}
+
+// promptDirs are where projects keep notes written for the model
+var promptDirs = []string{"./prompts", "./.prompts", "./docs/prompts"}
+
+// heavyDirSize is the size from which a directory that is mostly
+// binary or oversized files gets its own ignore entry
+const heavyDirSize = 1024 * 1024
+
+// ErrConfigExists is returned when dump_dir init would overwrite a config file
+type ErrConfigExists struct {
+	Path string
+}
+
+func (e ErrConfigExists) Error() string {
+	return fmt.Sprintf("%s already exists (use --force to overwrite it)", e.Path)
+}
+
+// ProjectScan is what dump_dir init learned about the working tree
+type ProjectScan struct {
+	// Languages are sorted by how many files use them
+	Languages []LanguageCount
+	Ignore    []IgnoreSuggestion
+	Readme    string
+	Prompts   string
+}
+
+type LanguageCount struct {
+	Language string
+	Files    int
+}
+
+// IgnoreSuggestion is a directory init proposes to ignore, with the
+// reason written next to it
+type IgnoreSuggestion struct {
+	Path   string
+	Reason string
+}
+
+type dirWeight struct {
+	total  int64
+	unused int64
+}
+
+// ScanProject walks the working tree looking for languages, dependency
+// and build directories, and directories of binary or oversized files.
+// Paths git already ignores are left out, since dump_dir skips them.
+func ScanProject(fs afero.Fs) ProjectScan {
+	var scan ProjectScan
+	ignoreManager, err := NewIgnoreManager(fs, false, nil)
+	if err != nil {
+		ignoreManager = nil
+	}
+
+	languages := make(map[string]int)
+	weights := make(map[string]*dirWeight)
+	afero.Walk(fs, ".", func(path string, info os.FileInfo, err error) error {
+		if err != nil || path == "." {
+			return nil
+		}
+		if ignoreManager != nil && ignoreManager.ShouldIgnore(path) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			if reason, ok := vendorDirs[info.Name()]; ok {
+				scan.Ignore = append(scan.Ignore, IgnoreSuggestion{Path: NormalizePath(path), Reason: reason})
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if language := DetectLanguage(path, ""); language != "" {
+			languages[language]++
+		}
+		unused := info.Size() > DefaultMaxFileSize || isBinaryPath(fs, path)
+		for dir := filepath.Dir(path); dir != "."; dir = filepath.Dir(dir) {
+			weight, ok := weights[dir]
+			if !ok {
+				weight = &dirWeight{}
+				weights[dir] = weight
+			}
+			weight.total += info.Size()
+			if unused {
+				weight.unused += info.Size()
+			}
+		}
+		return nil
+	})
+
+	for language, files := range languages {
+		scan.Languages = append(scan.Languages, LanguageCount{Language: language, Files: files})
+	}
+	sort.Slice(scan.Languages, func(i, j int) bool {
+		if scan.Languages[i].Files != scan.Languages[j].Files {
+			return scan.Languages[i].Files > scan.Languages[j].Files
+		}
+		return scan.Languages[i].Language < scan.Languages[j].Language
+	})
+
+	scan.Ignore = append(scan.Ignore, heavyDirs(weights)...)
+
+	for _, readme := range []string{"./README.md", "./README"} {
+		if exists, _ := afero.Exists(fs, readme); exists {
+			scan.Readme = readme
+			break
+		}
+	}
+	for _, prompts := range promptDirs {
+		if isDir, _ := afero.IsDir(fs, prompts); isDir {
+			scan.Prompts = prompts
+			break
+		}
+	}
+	return scan
+}
+
+// heavyDirs returns the outermost directories that are large and mostly
+// made of files dump_dir would skip anyway
+func heavyDirs(weights map[string]*dirWeight) []IgnoreSuggestion {
+	dirs := make([]string, 0, len(weights))
+	for dir := range weights {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	var suggestions []IgnoreSuggestion
+	var taken []string
+	for _, dir := range dirs {
+		weight := weights[dir]
+		if weight.total < heavyDirSize || weight.unused*2 < weight.total || isInside(dir, taken) {
+			continue
+		}
+		taken = append(taken, dir)
+		suggestions = append(suggestions, IgnoreSuggestion{
+			Path:   NormalizePath(dir),
+			Reason: fmt.Sprintf("%s, mostly binary or large files", formatBytes(weight.total)),
+		})
+	}
+	return suggestions
+}
+
+func isInside(dir string, parents []string) bool {
+	for _, parent := range parents {
+		if strings.HasPrefix(dir, parent+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func isBinaryPath(fs afero.Fs, path string) bool {
+	file, err := fs.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	isBinary, _ := fileIsBinary(file)
+	return isBinary
+}
+
+func formatBytes(size int64) string {
+	switch {
+	case size >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1024*1024))
+	case size >= 1024:
+		return fmt.Sprintf("%.1f KB", float64(size)/1024)
+	}
+	return fmt.Sprintf("%d B", size)
+}
+
+// GenerateConfig writes a commented config file for a scanned project
+func GenerateConfig(scan ProjectScan) string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	b.WriteString("# dump_dir config file, written by dump_dir init.\n")
+	b.WriteString("# Check it after editing with: dump_dir config validate\n")
+	if len(scan.Languages) > 0 {
+		languages := make([]string, 0, len(scan.Languages))
+		for _, language := range scan.Languages {
+			languages = append(languages, fmt.Sprintf("%s (%d)", language.Language, language.Files))
+		}
+		b.WriteString("#\n# Files found by language: " + strings.Join(languages, ", ") + "\n")
+	}
+
+	b.WriteString("\n# These files/directories will automatically\n# be included\n")
+	b.WriteString("include:\n")
+	if scan.Readme != "" {
+		fmt.Fprintf(&b, "  - %s\n", scan.Readme)
+	}
+	if scan.Prompts != "" {
+		fmt.Fprintf(&b, "  - %s\n", scan.Prompts)
+	} else {
+		b.WriteString("  # Notes for the model, like coding standards or an\n")
+		b.WriteString("  # architecture overview, are worth including in every dump:\n")
+		b.WriteString("  # - ./prompts\n")
+	}
+
+	b.WriteString("\n# These directories will always be skipped, even if\n# they are not in your .gitignore\n")
+	if len(scan.Ignore) == 0 {
+		b.WriteString("ignore:\n  # - ./dist\n")
+		return b.String()
+	}
+	width := 0
+	for _, suggestion := range scan.Ignore {
+		width = max(width, len(suggestion.Path))
+	}
+	b.WriteString("ignore:\n")
+	for _, suggestion := range scan.Ignore {
+		fmt.Fprintf(&b, "  - %-*s # %s\n", width, suggestion.Path, suggestion.Reason)
+	}
+	return b.String()
+}
+
+// InitConfig scans the working tree and writes a config file for it, or
+// prints it when config.Stdout is set
+func InitConfig(config Config, runConfig RunConfig) error {
+	fs := runConfig.Fs
+	if !config.Stdout && !config.Force {
+		if exists, _ := afero.Exists(fs, ConfigFileName); exists {
+			return ErrConfigExists{Path: ConfigFileName}
+		}
+	}
+
+	scan := ScanProject(fs)
+	contents := GenerateConfig(scan)
+	if config.Stdout {
+		fmt.Fprint(runConfig.Output(), contents)
+		return nil
+	}
+	if err := afero.WriteFile(fs, ConfigFileName, []byte(contents), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", ConfigFileName, err)
+	}
+
+	summary := fmt.Sprintf("✅ Wrote %s with %d ignore entries", ConfigFileName, len(scan.Ignore))
+	if len(scan.Ignore) == 1 {
+		summary = fmt.Sprintf("✅ Wrote %s with 1 ignore entry", ConfigFileName)
+	}
+	console := runConfig.Console(config)
+	fmt.Fprintln(console, BoldGreen(summary))
+	for _, suggestion := range scan.Ignore {
+		fmt.Fprintf(console, "- %s (%s)\n", suggestion.Path, suggestion.Reason)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package src
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+var languagesByFilename = map[string]string{
+	"Dockerfile":     "dockerfile",
+	"Containerfile":  "dockerfile",
+	"Makefile":       "makefile",
+	"GNUmakefile":    "makefile",
+	"makefile":       "makefile",
+	"CMakeLists.txt": "cmake",
+	"Jenkinsfile":    "groovy",
+	"Gemfile":        "ruby",
+	"Rakefile":       "ruby",
+	"Vagrantfile":    "ruby",
+	"Podfile":        "ruby",
+	"BUILD":          "starlark",
+	"WORKSPACE":      "starlark",
+	"go.mod":         "go",
+	"go.sum":         "text",
+	".bashrc":        "bash",
+	".bash_profile":  "bash",
+	".zshrc":         "zsh",
+	".profile":       "sh",
+	".gitignore":     "gitignore",
+	".dockerignore":  "gitignore",
+	".editorconfig":  "ini",
+	".env":           "dotenv",
+}
+
+var languagesByExtension = map[string]string{
+	".go":         "go",
+	".ts":         "ts",
+	".mts":        "ts",
+	".cts":        "ts",
+	".tsx":        "tsx",
+	".js":         "js",
+	".mjs":        "js",
+	".cjs":        "js",
+	".jsx":        "jsx",
+	".py":         "py",
+	".pyi":        "py",
+	".rb":         "ruby",
+	".rs":         "rust",
+	".java":       "java",
+	".kt":         "kotlin",
+	".kts":        "kotlin",
+	".scala":      "scala",
+	".swift":      "swift",
+	".c":          "c",
+	".h":          "c",
+	".cc":         "cpp",
+	".cpp":        "cpp",
+	".cxx":        "cpp",
+	".hpp":        "cpp",
+	".cs":         "csharp",
+	".fs":         "fsharp",
+	".php":        "php",
+	".pl":         "perl",
+	".lua":        "lua",
+	".r":          "r",
+	".dart":       "dart",
+	".ex":         "elixir",
+	".exs":        "elixir",
+	".erl":        "erlang",
+	".hs":         "haskell",
+	".clj":        "clojure",
+	".ml":         "ocaml",
+	".zig":        "zig",
+	".nim":        "nim",
+	".sh":         "sh",
+	".bash":       "bash",
+	".zsh":        "zsh",
+	".fish":       "fish",
+	".ps1":        "powershell",
+	".bat":        "batch",
+	".yaml":       "yaml",
+	".yml":        "yaml",
+	".json":       "json",
+	".jsonc":      "jsonc",
+	".toml":       "toml",
+	".ini":        "ini",
+	".cfg":        "ini",
+	".xml":        "xml",
+	".html":       "html",
+	".htm":        "html",
+	".css":        "css",
+	".scss":       "scss",
+	".sass":       "sass",
+	".less":       "less",
+	".vue":        "vue",
+	".svelte":     "svelte",
+	".sql":        "sql",
+	".graphql":    "graphql",
+	".gql":        "graphql",
+	".proto":      "protobuf",
+	".tf":         "hcl",
+	".hcl":        "hcl",
+	".md":         "markdown",
+	".mdx":        "mdx",
+	".rst":        "rst",
+	".tex":        "latex",
+	".dockerfile": "dockerfile",
+	".mk":         "makefile",
+	".cmake":      "cmake",
+	".gradle":     "groovy",
+	".groovy":     "groovy",
+	".diff":       "diff",
+	".patch":      "diff",
+	".csv":        "csv",
+	".txt":        "text",
+}
+
+var languagesByInterpreter = map[string]string{
+	"sh":      "sh",
+	"bash":    "bash",
+	"zsh":     "zsh",
+	"fish":    "fish",
+	"python":  "py",
+	"node":    "js",
+	"deno":    "ts",
+	"ts-node": "ts",
+	"ruby":    "ruby",
+	"perl":    "perl",
+	"php":     "php",
+	"lua":     "lua",
+	"Rscript": "r",
+}
+
+// DetectLanguage guesses the code fence language of a file from its
+// name, its extension and finally its shebang line.
+// It returns an empty string when nothing matches.
+func DetectLanguage(path, contents string) string {
+	filename := filepath.Base(path)
+	if language, ok := languagesByFilename[filename]; ok {
+		return language
+	}
+	if strings.HasPrefix(filename, "Dockerfile.") {
+		return "dockerfile"
+	}
+	if language, ok := languagesByExtension[strings.ToLower(filepath.Ext(filename))]; ok {
+		return language
+	}
+	return detectShebangLanguage(contents)
+}
+
+func detectShebangLanguage(contents string) string {
+	if !strings.HasPrefix(contents, "#!") {
+		return ""
+	}
+	firstLine, _, _ := strings.Cut(contents, "\n")
+	fields := strings.Fields(strings.TrimPrefix(firstLine, "#!"))
+	if len(fields) == 0 {
+		return ""
+	}
+
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		// Skip env flags such as -S to find the real interpreter
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") {
+				interpreter = filepath.Base(field)
+				break
+			}
+		}
+	}
+
+	// Strip version suffixes like python3.12
+	interpreter = strings.TrimRight(interpreter, "0123456789.")
+	return languagesByInterpreter[interpreter]
+}
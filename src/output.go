@@ -2,6 +2,7 @@ package src
 
 import (
 	"fmt"
+	"io"
 	"strings"
 )
 
@@ -9,6 +10,10 @@ func PrintUsage() {
 	usage := `
 ` + boldCyan("Usage:") + `
   dump_dir [options] <path1> [path2] [options] ...
+  dump_dir [options] <file:start-end> <file#symbol> ...
+  dump_dir ls [options] [path1] ...
+  dump_dir config validate [config file] ...
+  dump_dir init [--force] [--stdout]
 
 ` + boldCyan("Options:") + `
   -h, --help                 Display this help information
@@ -25,10 +30,71 @@ func PrintUsage() {
                              (e.g., 500KB, 2MB). If no unit is specified,
                              it defaults to 500KB.
   -g <pattern>, --glob <pattern>
-                             Match file names with a glob pattern.
-                             Does not support matching directory names
-                             or ** patterns.
+                             Match files with a glob pattern. Patterns
+                             with a / match the relative path, and **
+                             matches any number of directories. Start
+                             the pattern with ! to exclude matches.
+  -f <format>, --format <format>
+                             Choose how each file is wrapped in the output:
+                             plain (default), xml or markdown
+  --template <name>          Wrap the files in a prompt template, from the
+                             config file, .dump_dir/templates/<name>.tmpl
+                             or a .tmpl file path
+  --ask <question>           Add a question after the files, or wherever
+                             the template puts {{.Question}}. Use - to
+                             read the question from stdin.
+  --prompt-file <file>       Add the instructions in a file, before the
+                             --ask question
+  --prompt-position <where>  Put the question before or after (default)
+                             the files
+  --tree                     Show a tree of the dumped files before them
+  --tree-ignored             Also show the files and directories that were
+                             left out in the tree
+  --line-numbers             Number the lines of each file, so a model can
+                             refer to them
+  --tree-only                Copy only the tree, with the size, lines and
+                             tokens of each file, and no file contents
+  --top <n>                  List the n files and directories with the most
+                             tokens, to see what made the dump large
+  --tokenizer <name>         Count tokens with estimate (default), or the
+                             exact cl100k_base or o200k_base encodings
+  -t <tokens>, --max-tokens <tokens>
+                             Keep the output under an estimated token budget.
+                             Named files and config includes are kept first,
+                             then smaller and recently modified files.
+  --split-tokens <tokens>    Split the output at file boundaries into parts
+                             of at most this many estimated tokens
+  --split-size <size>        Split the output into parts of at most this
+                             size, using units like B, KB, or MB
+  --split-files              Write the parts to dump.part-N.txt files instead
+                             of copying them to the clipboard one at a time
+  --git-changed              Only dump files changed in the working tree,
+                             including untracked files
+  --git-staged               Only dump files staged for commit
+  --git-since <ref>          Only dump files changed since the branch split
+                             from <ref>, e.g. main
+  --diff                     Dump the git diff of each changed file instead
+                             of its contents
+  --diff-full                Dump the contents of each changed file followed
+                             by its diff
+  --diff-base <ref>          Diff against the branch split from <ref>.
+                             Defaults to the --git-since ref, or HEAD.
+  -o <file>, --output <file> Write the file contents to a file instead of
+                             the clipboard
+  --stdout                   Write the file contents to stdout instead of
+                             the clipboard. The summary goes to stderr.
+  --dry-run                  List what would be dumped and why each file was
+                             included or left out, without reading files.
+                             Same as dump_dir ls.
+  --report json              Write a JSON report of the run to stdout.
+                             The summary goes to stderr.
+  --report-file <file>       Write the JSON report to a file
+  -p <name>, --profile <name>
+                             Use a profile from .dump_dir.yml. The default
+                             profile is used when none is given.
   -nc, --no-config           Ignore the .dump_dir.yml configuration file
+  --no-<switch>              Turn off a switch the config file turns on,
+                             e.g. --no-stdout or --no-line-numbers
 
 ` + BoldGreen("Common examples:") + `
   # Grab everything from ./project
@@ -50,13 +116,62 @@ func PrintUsage() {
   # Grab all of the test files
   dump_dir ./project --glob "*_test.go"
 
+  # Grab the handlers, leaving out the tests
+  dump_dir . --glob "src/**/handlers/*.go" --glob "!**/*_test.go"
+
+  # Wrap each file in XML tags instead of START FILE/END FILE
+  dump_dir ./src --format xml
+
+  # Fit the dump into a 100k token context window
+  dump_dir . --max-tokens 100000
+
+  # Copy a large dump in parts of at most 30k tokens
+  dump_dir . --split-tokens 30000
+
+  # Grab the files you touched on this branch
+  dump_dir --git-since main
+
+  # Review this branch with the full files and their diffs
+  dump_dir --diff-full --diff-base main
+
+  # Find out why a file is missing from the dump
+  dump_dir ls ./src -e go
+
+  # Dump the frontend profile from .dump_dir.yml
+  dump_dir --profile frontend
+
+  # Write a .dump_dir.yml for a new project
+  dump_dir init
+
+  # Copy a ready-to-send review prompt
+  dump_dir ./src --template review --ask "is this thread safe?"
+
+  # Ask about a package with a question from another program
+  echo "find the bug" | dump_dir ./pkg --ask -
+
+  # Grab one function and a few lines instead of whole files
+  dump_dir ./src/run.go#performDumpDir ./main.go:40-120
+
+  # Ask for a fix that points at exact lines
+  dump_dir ./src/run.go --line-numbers --ask "where is the bug?"
+
+  # Show a large project's layout first, then dump the files you need
+  dump_dir . --tree-only --ask "Which files do you need to see?"
+
+  # Check the config files for typos, e.g. in CI
+  dump_dir config validate
+
+  # Pipe the dump into another program
+  dump_dir . --stdout | llm "review this"
+
 ` + boldMagenta("Description:") + `
   dump_dir will find files based on your parameters
   and put their contents into your clipboard in a way
   that is easy for Large Language Models to understand.
 
   You can make a .dump_dir.yml config file to automatically
-  include/exclude paths.
+  include/exclude paths and set any of the options above.
+  Options on the command line take precedence over it.
 
   More documentation at: https://github.com/fargusplumdoodle/dump_dir
 
@@ -64,14 +179,17 @@ func PrintUsage() {
 	fmt.Print(usage)
 }
 
-func PrintError(errorType string, filePath string, err error) {
-	fmt.Printf(boldRed("❌ Error %s file %s: %v\n", errorType, filePath, err))
+func PrintError(console io.Writer, errorType string, filePath string, err error) {
+	fmt.Fprintf(console, boldRed("❌ Error %s file %s: %v\n", errorType, filePath, err))
 }
 
-func CopyToClipboard(clipboard ClipboardManager, content string) bool {
+func CopyToClipboard(clipboard ClipboardManager, content string, console io.Writer) bool {
+	if limited, ok := clipboard.(TerminalLimitedClipboard); ok && limited.ExceedsTerminalLimit(content) {
+		fmt.Fprintln(console, "Warning: the output is larger than many terminals accept over OSC 52, so the clipboard may not be updated; consider --split-tokens or --stdout")
+	}
 	err := clipboard.WriteAll(content)
 	if err != nil {
-		fmt.Println(boldRed(fmt.Sprintf("❌ Error copying to clipboard: %v", err)))
+		fmt.Fprintln(console, boldRed(fmt.Sprintf("❌ Error copying to clipboard: %v", err)))
 		return false
 	}
 	return true
@@ -81,23 +199,74 @@ func FormatFileContent(path, contents string) string {
 	return fmt.Sprintf("START FILE: %s\n%s\nEND FILE: %s\n\n", path, contents, path)
 }
 
-func GenerateDetailedOutput(stats Stats) string {
-	var detailedOutput strings.Builder
+func FormatDiffContent(path, diff string) string {
+	return fmt.Sprintf("START DIFF: %s\n%s\nEND DIFF: %s\n\n", path, diff, path)
+}
+
+// FormatFiles formats every file that made it into the output
+func FormatFiles(stats Stats, formatter Formatter) []string {
+	var formattedFiles []string
 
 	for _, fileInfo := range stats.ProcessedFiles {
-		detailedOutput.WriteString(FormatFileContent(fileInfo.Path, fileInfo.Contents))
+		if fileInfo.Status == StatusSkippedOverBudget {
+			continue
+		}
+		formattedFiles = append(formattedFiles, FormatEntry(formatter, fileInfo))
 	}
 
-	return detailedOutput.String()
+	return formattedFiles
+}
+
+func GenerateDetailedOutput(stats Stats, formatter Formatter) string {
+	return strings.Join(FormatFiles(stats, formatter), "")
 }
 
-func PrintDetailedOutput(stats Stats, config RunConfig) {
-	detailedOutput := GenerateDetailedOutput(stats)
-	summary := DisplayStats(stats)
+func PrintDetailedOutput(data TemplateData, tokenizer Tokenizer, prompt *PromptTemplate, config Config, runConfig RunConfig) error {
+	var parts []string
+	if prompt != nil {
+		rendered, err := prompt.Render(data)
+		if err != nil {
+			return err
+		}
+		parts = []string{rendered}
+	} else {
+		parts = SplitIntoParts(data.Chunks(), config, tokenizer)
+	}
+	console := runConfig.Console(config)
+	written := "File contents have"
+	if config.TreeOnly {
+		written = "The tree has"
+	}
+	summary := DisplayStats(data.Stats)
+	if config.Top > 0 {
+		summary = strings.TrimSuffix(summary, "\n") + DisplayTopFiles(data.Stats, config.Top) + "\n"
+	}
 
-	if CopyToClipboard(config.Clipboard, detailedOutput) {
-		summary += BoldGreen("✅ File contents have been copied to clipboard.\n")
+	switch {
+	case config.Stdout:
+		fmt.Fprint(runConfig.Output(), strings.Join(parts, "\n"))
+		summary += BoldGreen(fmt.Sprintf("✅ %s been written to stdout.\n", written))
+	case config.OutputFile != "" || (config.SplitToFiles && len(parts) > 1):
+		paths, err := WriteOutputFiles(parts, config.OutputFile, runConfig.Fs)
+		if err != nil {
+			return err
+		}
+		if len(paths) > 1 {
+			summary += BoldGreen(fmt.Sprintf("✅ %s been split into %d parts: %s\n", written, len(paths), strings.Join(paths, ", ")))
+		} else {
+			summary += BoldGreen(fmt.Sprintf("✅ %s been written to %s\n", written, paths[0]))
+		}
+	case len(parts) == 1:
+		if CopyToClipboard(runConfig.Clipboard, parts[0], console) {
+			summary += BoldGreen(fmt.Sprintf("✅ %s been copied to clipboard.\n", written))
+		}
+	default:
+		summary += boldCyan(fmt.Sprintf("✂️ Output has been split into %d parts.\n", len(parts)))
+		fmt.Fprintln(console, summary)
+		CopyPartsInteractively(parts, runConfig, console)
+		return nil
 	}
 
-	fmt.Println(summary)
+	fmt.Fprintln(console, summary)
+	return nil
 }
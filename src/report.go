@@ -0,0 +1,138 @@
+package src
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// ReportVersion is bumped whenever a field of the report is renamed or
+// removed. Adding fields does not change it.
+const ReportVersion = 1
+
+const ReportFormatJSON = "json"
+
+// Report is the machine-readable summary of a run, for editor plugins
+// and CI scripts
+type Report struct {
+	Version        int          `json:"version"`
+	DumpDirVersion string       `json:"dump_dir_version"`
+	Config         Config       `json:"config"`
+	Files          []ReportFile `json:"files"`
+	Totals         ReportTotals `json:"totals"`
+	Timing         ReportTiming `json:"timing"`
+}
+
+type ReportFile struct {
+	Path       string     `json:"path"`
+	Status     FileStatus `json:"status"`
+	Size       int64      `json:"size"`
+	Lines      int        `json:"lines"`
+	Tokens     int        `json:"tokens"`
+	SkipReason string     `json:"skip_reason,omitempty"`
+}
+
+type ReportTotals struct {
+	Files             int `json:"files"`
+	Parsed            int `json:"parsed"`
+	SkippedLarge      int `json:"skipped_large"`
+	SkippedBinary     int `json:"skipped_binary"`
+	SkippedOverBudget int `json:"skipped_over_budget"`
+	Lines             int `json:"lines"`
+	Tokens            int `json:"tokens"`
+}
+
+// ReportTiming records when the run started and how long each phase took
+type ReportTiming struct {
+	StartedAt    time.Time `json:"started_at"`
+	DiscoveryMs  float64   `json:"discovery_ms"`
+	ProcessingMs float64   `json:"processing_ms"`
+	OutputMs     float64   `json:"output_ms"`
+	TotalMs      float64   `json:"total_ms"`
+}
+
+// NewReport builds the report for a finished run
+func NewReport(stats Stats, config Config, timing ReportTiming, version string) Report {
+	if config.Format == "" {
+		config.Format = DefaultFormat
+	}
+	if config.Tokenizer == "" {
+		config.Tokenizer = DefaultTokenizer
+	}
+	// Empty lists are written as [] rather than null
+	for _, list := range []*[]string{
+		&config.Extensions, &config.Directories, &config.SkipDirs, &config.SpecificFiles, &config.GlobPatterns,
+	} {
+		if *list == nil {
+			*list = []string{}
+		}
+	}
+
+	files := make([]ReportFile, 0, len(stats.ProcessedFiles))
+	for _, fileInfo := range stats.ProcessedFiles {
+		files = append(files, ReportFile{
+			Path:       fileInfo.Path,
+			Status:     fileInfo.Status,
+			Size:       fileInfo.Size,
+			Lines:      fileInfo.Lines,
+			Tokens:     fileInfo.Tokens,
+			SkipReason: skipReason(fileInfo, config),
+		})
+	}
+
+	return Report{
+		Version:        ReportVersion,
+		DumpDirVersion: version,
+		Config:         config,
+		Files:          files,
+		Totals: ReportTotals{
+			Files:             stats.TotalFiles,
+			Parsed:            len(stats.ParsedFiles),
+			SkippedLarge:      len(stats.SkippedLarge),
+			SkippedBinary:     len(stats.SkippedBinary),
+			SkippedOverBudget: len(stats.SkippedOverBudget),
+			Lines:             stats.TotalLines,
+			Tokens:            stats.EstimatedTokens,
+		},
+		Timing: timing,
+	}
+}
+
+func skipReason(fileInfo FileInfo, config Config) string {
+	switch fileInfo.Status {
+	case StatusParsed:
+		return ""
+	case StatusSkippedBinary:
+		return "binary file"
+	case StatusSkippedTooLarge:
+		return fmt.Sprintf("larger than max_filesize (%d bytes)", config.MaxFileSize)
+	case StatusSkippedOverBudget:
+		return fmt.Sprintf("over the token budget (%d tokens)", config.MaxTokens)
+	default:
+		return "line too long to read"
+	}
+}
+
+// WriteReport writes the report to the report file, or to stdout
+func WriteReport(report Report, config Config, runConfig RunConfig) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding report: %w", err)
+	}
+	data = append(data, '\n')
+
+	if config.ReportFile == "" {
+		_, err = runConfig.Output().Write(data)
+		return err
+	}
+	if err := afero.WriteFile(runConfig.Fs, config.ReportFile, data, 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", config.ReportFile, err)
+	}
+	return nil
+}
+
+func millisecondsSince(start time.Time) float64 {
+	return float64(time.Since(start).Microseconds()) / 1000
+}
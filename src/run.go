@@ -1,6 +1,9 @@
 package src
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 func Run(args []string, config RunConfig) error {
 	if !ValidateArgs(args) {
@@ -23,26 +26,93 @@ func Run(args []string, config RunConfig) error {
 		return nil
 	case "dump_dir":
 		return performDumpDir(cliConfig, config)
+	case "init":
+		return InitConfig(cliConfig, config)
+	case "validate_config":
+		return NewConfigLoader(config.Fs).ValidateConfigFiles(cliConfig.ConfigFiles, config.Console(cliConfig))
 	default:
 		return fmt.Errorf("unknown action: %s", cliConfig.Action)
 	}
 }
 
 func performDumpDir(cliArgumentsConfig Config, runConfig RunConfig) error {
+	timing := ReportTiming{StartedAt: time.Now()}
 	configLoader := NewConfigLoader(runConfig.Fs)
 	config, err := configLoader.LoadAndMergeConfig(cliArgumentsConfig)
 	if err != nil {
 		return fmt.Errorf("error loading config: %v", err)
 	}
+	console := runConfig.Console(config)
+	for _, warning := range config.Warnings {
+		fmt.Fprintln(console, warning)
+	}
+
+	formatter, err := NewFormatter(config.Format)
+	if err != nil {
+		return err
+	}
+
+	tokenizer, err := NewTokenizer(config.Tokenizer)
+	if err != nil {
+		return err
+	}
 
-	fileFinder := NewFileFinder(config, runConfig.Fs)
-	fileProcessor := NewFileProcessor(runConfig.Fs, config)
+	prompt, err := configLoader.LoadPromptTemplate(config)
+	if err != nil {
+		return err
+	}
+	config.Question, err = ReadQuestion(config, runConfig)
+	if err != nil {
+		return err
+	}
 
-	filePaths := fileFinder.DiscoverFiles()
-	processedFiles := fileProcessor.ProcessFiles(filePaths)
-	stats := CalculateStats(processedFiles)
-	PrintDetailedOutput(stats, runConfig)
-	return nil
+	fileFinder := NewFileFinder(config, runConfig.Fs, console)
+	fileProcessor := NewFileProcessor(runConfig.Fs, config, console)
+	if config.Diff != DiffModeNone {
+		fileProcessor.DiffBase, err = MergeBase(config.DiffRef())
+		if err != nil {
+			return fmt.Errorf("error resolving diff base: %v", err)
+		}
+	}
+
+	phaseStart := time.Now()
+	fileFinder.RecordDecisions = config.DryRun || config.TreeIgnored
+	filePaths, err := fileFinder.DiscoverFiles()
+	if err != nil {
+		return fmt.Errorf("error discovering files: %v", err)
+	}
+	timing.DiscoveryMs = millisecondsSince(phaseStart)
+
+	if config.DryRun {
+		PrintDryRun(fileFinder.Decisions, config, runConfig)
+		return nil
+	}
+
+	phaseStart = time.Now()
+	processedFiles, err := fileProcessor.ProcessFiles(filePaths)
+	if err != nil {
+		return err
+	}
+	// The budget is for contents, which a tree-only dump leaves out
+	if !config.TreeOnly {
+		processedFiles = NewTokenBudget(config, formatter, tokenizer).Apply(processedFiles, fileFinder.Decisions)
+	}
+	stats := CalculateStats(processedFiles, tokenizer)
+	timing.ProcessingMs = millisecondsSince(phaseStart)
+
+	phaseStart = time.Now()
+	tree := RenderTree(BuildTree(stats, fileFinder.Decisions, config.TreeOnly))
+	data := NewTemplateData(stats, formatter, tree, config)
+	if err := PrintDetailedOutput(data, tokenizer, prompt, config, runConfig); err != nil {
+		return err
+	}
+	timing.OutputMs = millisecondsSince(phaseStart)
+
+	if config.Report == "" {
+		return nil
+	}
+	timing.TotalMs = millisecondsSince(timing.StartedAt)
+	return WriteReport(NewReport(stats, config, timing, runConfig.Version), config, runConfig)
 }
 
 func PrintVersion(cfg RunConfig) {
@@ -0,0 +1,426 @@
+package src
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FileSelector picks a region of a file given on the command line,
+// either a range of lines or a named symbol like a function
+type FileSelector struct {
+	Start  int
+	End    int
+	Symbol string
+}
+
+func (s FileSelector) String() string {
+	if s.Symbol != "" {
+		return "#" + s.Symbol
+	}
+	if s.Start == s.End {
+		return fmt.Sprintf(":%d", s.Start)
+	}
+	return fmt.Sprintf(":%d-%d", s.Start, s.End)
+}
+
+// ErrInvalidSelector is a custom error type for selectors that cannot be used
+type ErrInvalidSelector struct {
+	Value  string
+	Reason string
+}
+
+func (e ErrInvalidSelector) Error() string {
+	return fmt.Sprintf("invalid selector %s: %s", e.Value, e.Reason)
+}
+
+var (
+	lineRangeSelector = regexp.MustCompile(`^(.+):(\d+)(?:-(\d+))?$`)
+	symbolSelector    = regexp.MustCompile(`^(.+)#([A-Za-z_$][\w$]*(?:\.[A-Za-z_$][\w$]*)*)$`)
+)
+
+// SplitFileSelector separates a selector like main.go:40-120 or
+// run.go#performDumpDir from the path it selects in. ok is false when
+// the argument has no selector.
+func SplitFileSelector(arg string) (path string, selector FileSelector, ok bool, err error) {
+	if match := symbolSelector.FindStringSubmatch(arg); match != nil {
+		return match[1], FileSelector{Symbol: match[2]}, true, nil
+	}
+	match := lineRangeSelector.FindStringSubmatch(arg)
+	if match == nil {
+		return arg, FileSelector{}, false, nil
+	}
+
+	start, _ := strconv.Atoi(match[2])
+	end := start
+	if match[3] != "" {
+		end, _ = strconv.Atoi(match[3])
+	}
+	if start < 1 || end < start {
+		return "", FileSelector{}, false, ErrInvalidSelector{Value: arg, Reason: "lines are counted from 1 and the range must not run backwards"}
+	}
+	return match[1], FileSelector{Start: start, End: end}, true, nil
+}
+
+// ParseFileSelector is SplitFileSelector for paths on disk. A file
+// whose whole name looks like a selector is taken as it is.
+func ParseFileSelector(arg string) (string, *FileSelector, error) {
+	if _, err := OsStat(arg); err == nil {
+		return arg, nil, nil
+	}
+	path, selector, ok, err := SplitFileSelector(arg)
+	if err != nil || !ok {
+		return arg, nil, err
+	}
+	return path, &selector, nil
+}
+
+// Region is a run of lines picked out of a file, counted from 1
+type Region struct {
+	Start int
+	End   int
+	// Symbol is the name the region was selected by, if any
+	Symbol string
+}
+
+func (r Region) String() string {
+	lines := fmt.Sprintf("lines %d-%d", r.Start, r.End)
+	if r.Start == r.End {
+		lines = fmt.Sprintf("line %d", r.Start)
+	}
+	if r.Symbol != "" {
+		return r.Symbol + ", " + lines
+	}
+	return lines
+}
+
+// SelectRegions finds the lines each selector picks out of a file,
+// in the order they appear in it. Regions that overlap or touch are
+// merged, so no line is shown twice.
+func SelectRegions(path, contents string, selectors []FileSelector) ([]Region, error) {
+	lines := splitLines(contents)
+	regions := make([]Region, 0, len(selectors))
+	for _, selector := range selectors {
+		if selector.Symbol == "" {
+			if selector.Start > len(lines) {
+				return nil, ErrInvalidSelector{Value: path + selector.String(), Reason: fmt.Sprintf("starts after the end of the file (%d lines)", len(lines))}
+			}
+			regions = append(regions, Region{Start: selector.Start, End: min(selector.End, len(lines))})
+			continue
+		}
+
+		region, found := findSymbol(path, contents, lines, selector.Symbol)
+		if !found {
+			return nil, ErrInvalidSelector{Value: path + selector.String(), Reason: "symbol not found"}
+		}
+		regions = append(regions, region)
+	}
+
+	sort.SliceStable(regions, func(i, j int) bool {
+		return regions[i].Start < regions[j].Start
+	})
+	return mergeRegions(regions), nil
+}
+
+// mergeRegions joins sorted regions that overlap or touch, keeping the
+// names of the symbols in them
+func mergeRegions(regions []Region) []Region {
+	merged := make([]Region, 0, len(regions))
+	for _, region := range regions {
+		last := len(merged) - 1
+		if last < 0 || region.Start > merged[last].End+1 {
+			merged = append(merged, region)
+			continue
+		}
+		merged[last].End = max(merged[last].End, region.End)
+		switch {
+		case merged[last].Symbol == "":
+			merged[last].Symbol = region.Symbol
+		case region.Symbol != "" && region.Symbol != merged[last].Symbol:
+			merged[last].Symbol += " and " + region.Symbol
+		}
+	}
+	return merged
+}
+
+// ExtractRegions joins the lines of each region, with a ... line
+// marking the lines left out between them. Line numbers share one
+// gutter, as wide as the highest number in any region.
+func ExtractRegions(contents string, regions []Region, lineNumbers bool) string {
+	lines := splitLines(contents)
+	last := 0
+	for _, region := range regions {
+		last = max(last, region.End)
+	}
+	var extracted strings.Builder
+	for i, region := range regions {
+		if i > 0 {
+			extracted.WriteString("...\n")
+		}
+		text := withTrailingNewline(strings.Join(lines[region.Start-1:region.End], ""))
+		if lineNumbers {
+			text = NumberLines(text, region.Start, GutterWidth(last))
+		}
+		extracted.WriteString(text)
+	}
+	return extracted.String()
+}
+
+// DescribeRegions is the header of a file cut down to regions, e.g.
+// "performDumpDir, lines 40-120 of 300"
+func DescribeRegions(regions []Region, totalLines int) string {
+	descriptions := make([]string, len(regions))
+	for i, region := range regions {
+		descriptions[i] = region.String()
+	}
+	return fmt.Sprintf("%s of %d", strings.Join(descriptions, "; "), totalLines)
+}
+
+func splitLines(contents string) []string {
+	lines := strings.SplitAfter(contents, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// findSymbol looks up a symbol with the Go parser in Go files, and with
+// findSymbolHeuristic in everything else. Type.Method finds a method,
+// or a member inside the named type.
+func findSymbol(path, contents string, lines []string, symbol string) (Region, bool) {
+	if filepath.Ext(path) == ".go" {
+		if region, found, ok := findGoSymbol(path, contents, symbol); ok {
+			return region, found
+		}
+	}
+
+	first, last := 1, len(lines)
+	names := strings.Split(symbol, ".")
+	var region Region
+	for _, name := range names {
+		var found bool
+		region, found = findSymbolHeuristic(lines, name, first, last)
+		if !found {
+			return Region{}, false
+		}
+		first, last = region.Start+1, region.End
+	}
+	region.Symbol = symbol
+	return region, true
+}
+
+// findGoSymbol finds a function, method, type, variable or constant,
+// with its doc comment. A method can be named without its type when
+// nothing else has the name. ok is false when the file does not parse.
+func findGoSymbol(path, contents, symbol string) (region Region, found bool, ok bool) {
+	fileSet := token.NewFileSet()
+	file, err := parser.ParseFile(fileSet, path, contents, parser.ParseComments)
+	if err != nil {
+		return Region{}, false, false
+	}
+	regionOf := func(start, end token.Pos) Region {
+		return Region{Start: fileSet.Position(start).Line, End: fileSet.Position(end).Line, Symbol: symbol}
+	}
+
+	for _, declaration := range file.Decls {
+		switch declaration := declaration.(type) {
+		case *ast.FuncDecl:
+			if goFuncName(declaration) == symbol {
+				return regionOf(startWithDoc(declaration.Doc, declaration.Pos()), declaration.End()), true, true
+			}
+		case *ast.GenDecl:
+			for _, spec := range declaration.Specs {
+				if !specDeclares(spec, symbol) {
+					continue
+				}
+				// A lone spec takes its keyword and the comment above it
+				if len(declaration.Specs) == 1 {
+					return regionOf(startWithDoc(declaration.Doc, declaration.Pos()), declaration.End()), true, true
+				}
+				return regionOf(startWithDoc(specDoc(spec), spec.Pos()), spec.End()), true, true
+			}
+		}
+	}
+	for _, declaration := range file.Decls {
+		if function, isFunc := declaration.(*ast.FuncDecl); isFunc && function.Name.Name == symbol {
+			return regionOf(startWithDoc(function.Doc, function.Pos()), function.End()), true, true
+		}
+	}
+	return Region{}, false, true
+}
+
+// goFuncName is the name of a function, or Type.Method for a method
+func goFuncName(function *ast.FuncDecl) string {
+	if function.Recv == nil || len(function.Recv.List) == 0 {
+		return function.Name.Name
+	}
+	receiver := function.Recv.List[0].Type
+	for {
+		switch expression := receiver.(type) {
+		case *ast.StarExpr:
+			receiver = expression.X
+			continue
+		case *ast.IndexExpr:
+			receiver = expression.X
+			continue
+		case *ast.IndexListExpr:
+			receiver = expression.X
+			continue
+		case *ast.Ident:
+			return expression.Name + "." + function.Name.Name
+		}
+		return function.Name.Name
+	}
+}
+
+func specDeclares(spec ast.Spec, symbol string) bool {
+	switch spec := spec.(type) {
+	case *ast.TypeSpec:
+		return spec.Name.Name == symbol
+	case *ast.ValueSpec:
+		for _, name := range spec.Names {
+			if name.Name == symbol {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func specDoc(spec ast.Spec) *ast.CommentGroup {
+	switch spec := spec.(type) {
+	case *ast.TypeSpec:
+		return spec.Doc
+	case *ast.ValueSpec:
+		return spec.Doc
+	}
+	return nil
+}
+
+func startWithDoc(doc *ast.CommentGroup, pos token.Pos) token.Pos {
+	if doc != nil {
+		return doc.Pos()
+	}
+	return pos
+}
+
+const definitionKeywords = `func|function|def|class|fn|type|interface|struct|enum|trait|impl|module|const|let|var|val|object|record|namespace`
+
+// findSymbolHeuristic finds the definition of name between the first
+// and last lines, for languages without a parser. A line defining it
+// starts with a keyword like def, class or function, or opens a block
+// the way a method in a class does. The definition ends where its
+// braces close or, without braces, where the indentation returns to
+// that of the definition. Comments and decorators right above it are
+// included.
+func findSymbolHeuristic(lines []string, name string, first, last int) (Region, bool) {
+	quoted := regexp.QuoteMeta(name)
+	patterns := []*regexp.Regexp{
+		regexp.MustCompile(`\b(?:` + definitionKeywords + `)\s+` + quoted + `\b`),
+		regexp.MustCompile(`^\s*(?:[\w<>\[\],.*&?]+\s+)*` + quoted + `\s*\(.*\)[^;]*\{\s*$`),
+	}
+	for _, pattern := range patterns {
+		for i := first - 1; i < last; i++ {
+			if pattern.MatchString(lines[i]) {
+				start := i + 1
+				return Region{Start: leadingCommentStart(lines, start, first), End: definitionEnd(lines, start, last)}, true
+			}
+		}
+	}
+	return Region{}, false
+}
+
+// definitionEnd returns the last line of the definition starting at
+// start: where its brackets close, or the end of the block indented
+// below it
+func definitionEnd(lines []string, start, last int) int {
+	depth, sawBrace := 0, false
+	for i := start - 1; i < last; i++ {
+		depth += strings.Count(lines[i], "{") + strings.Count(lines[i], "(") + strings.Count(lines[i], "[")
+		depth -= strings.Count(lines[i], "}") + strings.Count(lines[i], ")") + strings.Count(lines[i], "]")
+		sawBrace = sawBrace || strings.Contains(lines[i], "{")
+		if depth > 0 {
+			continue
+		}
+		if sawBrace {
+			return i + 1
+		}
+		next := nextNonBlankLine(lines, i+1, last)
+		if next == -1 {
+			return i + 1
+		}
+		// The brace of the block can be on its own line
+		if strings.HasPrefix(strings.TrimSpace(lines[next]), "{") {
+			continue
+		}
+		if indentation(lines[next]) > indentation(lines[start-1]) {
+			return indentedBlockEnd(lines, start, next, last)
+		}
+		return i + 1
+	}
+	return last
+}
+
+// indentedBlockEnd returns the last line indented deeper than the
+// definition, and a closing end keyword on the definition's level
+func indentedBlockEnd(lines []string, start, from, last int) int {
+	indent := indentation(lines[start-1])
+	end := from
+	for i := from; i < last; i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" {
+			continue
+		}
+		if indentation(lines[i]) <= indent {
+			if trimmed == "end" || strings.HasPrefix(trimmed, "end ") {
+				end = i + 1
+			}
+			break
+		}
+		end = i + 1
+	}
+	return end
+}
+
+// nextNonBlankLine returns the index of the first line from index i
+// with anything on it, or -1
+func nextNonBlankLine(lines []string, i, last int) int {
+	for ; i < last; i++ {
+		if strings.TrimSpace(lines[i]) != "" {
+			return i
+		}
+	}
+	return -1
+}
+
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// leadingCommentStart moves the start of a definition up over the
+// comments and decorators directly above it
+func leadingCommentStart(lines []string, start, first int) int {
+	for start > first {
+		above := strings.TrimSpace(lines[start-2])
+		if !isCommentOrDecorator(above) {
+			break
+		}
+		start--
+	}
+	return start
+}
+
+func isCommentOrDecorator(line string) bool {
+	for _, prefix := range []string{"//", "#", "/*", "*", "@", "--", ";;", "\"\"\""} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
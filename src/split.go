@@ -0,0 +1,129 @@
+package src
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/spf13/afero"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+const PartFileTemplate = "dump.part-%d.txt"
+
+// SplitIntoParts groups the chunks of the output, such as the tree and
+// the formatted files, into parts that stay under the configured token
+// or byte limit. Chunks are never split, so a single file larger than
+// the limit gets a part of its own.
+func SplitIntoParts(chunks []string, config Config, tokenizer Tokenizer) []string {
+	if config.SplitTokens <= 0 && config.SplitSize <= 0 {
+		return []string{strings.Join(chunks, "")}
+	}
+
+	var groups [][]string
+	var current []string
+	currentTokens, currentBytes := 0, 0
+
+	for _, formatted := range chunks {
+		tokens := 0
+		if config.SplitTokens > 0 {
+			tokens = tokenizer.CountTokens(formatted)
+		}
+		overTokens := config.SplitTokens > 0 && currentTokens+tokens > config.SplitTokens
+		overBytes := config.SplitSize > 0 && int64(currentBytes+len(formatted)) > config.SplitSize
+
+		if len(current) > 0 && (overTokens || overBytes) {
+			groups = append(groups, current)
+			current, currentTokens, currentBytes = nil, 0, 0
+		}
+		current = append(current, formatted)
+		currentTokens += tokens
+		currentBytes += len(formatted)
+	}
+	if len(current) > 0 || len(groups) == 0 {
+		groups = append(groups, current)
+	}
+
+	if len(groups) == 1 {
+		return []string{strings.Join(groups[0], "")}
+	}
+
+	parts := make([]string, len(groups))
+	for i, group := range groups {
+		parts[i] = formatPart(i+1, len(groups), strings.Join(group, ""))
+	}
+	return parts
+}
+
+func formatPart(number, total int, contents string) string {
+	var part strings.Builder
+	part.WriteString(fmt.Sprintf("=== Part %d of %d ===\n\n", number, total))
+	part.WriteString(contents)
+	if number < total {
+		part.WriteString(fmt.Sprintf("=== End of part %d of %d. More parts follow, wait for all of them before responding. ===\n", number, total))
+	}
+	return part.String()
+}
+
+// SplitsInteractively reports whether the parts are copied to the
+// clipboard one at a time, which reads Enter presses from stdin
+func (c *Config) SplitsInteractively() bool {
+	return (c.SplitTokens > 0 || c.SplitSize > 0) && !c.SplitToFiles && !c.Stdout && c.OutputFile == ""
+}
+
+// CopyPartsInteractively copies one part at a time, waiting for the user
+// to press enter before replacing the clipboard with the next part
+func CopyPartsInteractively(parts []string, runConfig RunConfig, console io.Writer) {
+	stdin := runConfig.Stdin
+	if stdin == nil {
+		stdin = strings.NewReader("")
+	}
+	input := bufio.NewReader(stdin)
+	for i, part := range parts {
+		if !CopyToClipboard(runConfig.Clipboard, part, console) {
+			return
+		}
+		fmt.Fprint(console, BoldGreen(fmt.Sprintf("✅ Part %d of %d has been copied to clipboard.\n", i+1, len(parts))))
+
+		if i == len(parts)-1 {
+			return
+		}
+		fmt.Fprint(console, "Paste it, then press Enter to copy the next part...")
+		if _, err := input.ReadString('\n'); err != nil {
+			fmt.Fprintln(console, boldRed(fmt.Sprintf("\n❌ No more input, stopped after part %d of %d", i+1, len(parts))))
+			return
+		}
+	}
+}
+
+// WriteOutputFiles writes the output to outputFile, or each part to its
+// own numbered file when the output has been split
+func WriteOutputFiles(parts []string, outputFile string, fs afero.Fs) ([]string, error) {
+	paths := []string{outputFile}
+	if len(parts) > 1 {
+		paths = partFilePaths(len(parts), outputFile)
+	}
+
+	for i, part := range parts {
+		if err := afero.WriteFile(fs, paths[i], []byte(part), 0644); err != nil {
+			return nil, fmt.Errorf("writing %s: %w", paths[i], err)
+		}
+	}
+	return paths, nil
+}
+
+// partFilePaths names parts dump.part-N.txt, or after the output file
+// when one was given, so out.txt becomes out.part-1.txt, out.part-2.txt, ...
+func partFilePaths(count int, outputFile string) []string {
+	template := PartFileTemplate
+	if outputFile != "" {
+		ext := filepath.Ext(outputFile)
+		template = strings.ReplaceAll(strings.TrimSuffix(outputFile, ext), "%", "%%") + ".part-%d" + ext
+	}
+
+	paths := make([]string, count)
+	for i := range paths {
+		paths[i] = fmt.Sprintf(template, i+1)
+	}
+	return paths
+}
@@ -2,46 +2,53 @@ package src
 
 import (
 	"fmt"
+	"path/filepath"
 	"sort"
 	"strings"
 )
 
-func CalculateStats(processedFiles []FileInfo) Stats {
+func CalculateStats(processedFiles []FileInfo, tokenizer Tokenizer) Stats {
 	var totalLines, estimatedTokens int
-	var skippedLarge, skippedBinary, parsedFiles []FileInfo
+	var skippedLarge, skippedBinary, skippedOverBudget, parsedFiles []FileInfo
 
 	sortedFiles := SortFileList(processedFiles)
-	tokenEstimator := NewTokenEstimator()
 
-	for _, fileInfo := range sortedFiles {
+	for i, fileInfo := range sortedFiles {
 		switch fileInfo.Status {
 		case StatusParsed:
-			totalLines += strings.Count(fileInfo.Contents, "\n")
-			estimatedTokens += tokenEstimator.EstimateTokens(fileInfo.Contents)
+			fileInfo.Lines = strings.Count(fileInfo.Contents, "\n") + strings.Count(fileInfo.Diff, "\n")
+			fileInfo.Tokens = tokenizer.CountTokens(fileInfo.Contents) + tokenizer.CountTokens(fileInfo.Diff)
+			sortedFiles[i] = fileInfo
+			totalLines += fileInfo.Lines
+			estimatedTokens += fileInfo.Tokens
 			parsedFiles = append(parsedFiles, fileInfo)
 		case StatusSkippedTooLarge:
 			skippedLarge = append(skippedLarge, fileInfo)
 		case StatusSkippedBinary:
 			skippedBinary = append(skippedBinary, fileInfo)
+		case StatusSkippedOverBudget:
+			skippedOverBudget = append(skippedOverBudget, fileInfo)
 		}
 	}
 
 	return Stats{
-		TotalFiles:      len(processedFiles),
-		TotalLines:      totalLines,
-		EstimatedTokens: estimatedTokens,
-		ProcessedFiles:  sortedFiles,
-		ParsedFiles:     parsedFiles,
-		SkippedLarge:    skippedLarge,
-		SkippedBinary:   skippedBinary,
+		TotalFiles:        len(processedFiles),
+		TotalLines:        totalLines,
+		EstimatedTokens:   estimatedTokens,
+		ProcessedFiles:    sortedFiles,
+		ParsedFiles:       parsedFiles,
+		SkippedLarge:      skippedLarge,
+		SkippedBinary:     skippedBinary,
+		SkippedOverBudget: skippedOverBudget,
 	}
 }
 
 func DisplayStats(stats Stats) string {
 	var summary strings.Builder
-	printFileList(&summary, "🔍 Parsed files:", stats.ParsedFiles)
+	printParsedFileList(&summary, stats)
 	printFileList(&summary, "🪨 Skipped large files:", stats.SkippedLarge)
 	printFileList(&summary, "💽 Skipped binary files:", stats.SkippedBinary)
+	printFileList(&summary, "✂️ Skipped (over budget):", stats.SkippedOverBudget)
 
 	summary.WriteString(boldCyan(fmt.Sprintf("\n📚 Total files found: %d\n", stats.TotalFiles)))
 	summary.WriteString(boldCyan(fmt.Sprintf("📝 Total lines across all parsed files: %d\n", stats.TotalLines)))
@@ -61,6 +68,96 @@ func printFileList(summary *strings.Builder, heading string, files []FileInfo) {
 	}
 }
 
+// printParsedFileList lists each parsed file with its lines, tokens
+// and share of the total tokens, in aligned columns
+func printParsedFileList(summary *strings.Builder, stats Stats) {
+	if len(stats.ParsedFiles) == 0 {
+		return
+	}
+	summary.WriteString(boldMagenta("\n🔍 Parsed files:\n"))
+
+	pathWidth := 0
+	for _, file := range stats.ParsedFiles {
+		pathWidth = max(pathWidth, len(file.Path))
+	}
+	for _, file := range stats.ParsedFiles {
+		summary.WriteString(fmt.Sprintf(
+			"- %-*s %7d lines %8d tokens %6s\n",
+			pathWidth, file.Path, file.Lines, file.Tokens, formatShare(file.Tokens, stats.EstimatedTokens),
+		))
+	}
+}
+
+// DisplayTopFiles ranks the files and directories with the most tokens,
+// to find what made a dump large
+func DisplayTopFiles(stats Stats, count int) string {
+	type entry struct {
+		path   string
+		tokens int
+		detail string
+	}
+	rank := func(entries []entry) []entry {
+		sort.SliceStable(entries, func(i, j int) bool {
+			if entries[i].tokens != entries[j].tokens {
+				return entries[i].tokens > entries[j].tokens
+			}
+			return entries[i].path < entries[j].path
+		})
+		return entries[:min(count, len(entries))]
+	}
+
+	var files []entry
+	dirTokens := make(map[string]int)
+	dirFiles := make(map[string]int)
+	for _, file := range stats.ParsedFiles {
+		files = append(files, entry{file.Path, file.Tokens, fmt.Sprintf("%d lines", file.Lines)})
+		for dir := filepath.Dir(file.Path); dir != "." && dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+			dirTokens[NormalizePath(dir)] += file.Tokens
+			dirFiles[NormalizePath(dir)]++
+		}
+	}
+	var dirs []entry
+	for dir, tokens := range dirTokens {
+		detail := fmt.Sprintf("%d files", dirFiles[dir])
+		if dirFiles[dir] == 1 {
+			detail = "1 file"
+		}
+		dirs = append(dirs, entry{dir, tokens, detail})
+	}
+
+	var summary strings.Builder
+	for _, section := range []struct {
+		heading string
+		entries []entry
+	}{
+		{"🏋️ Heaviest files:", rank(files)},
+		{"🏋️ Heaviest directories:", rank(dirs)},
+	} {
+		if len(section.entries) == 0 {
+			continue
+		}
+		summary.WriteString(boldMagenta(fmt.Sprintf("\n%s\n", section.heading)))
+		pathWidth := 0
+		for _, e := range section.entries {
+			pathWidth = max(pathWidth, len(e.path))
+		}
+		for i, e := range section.entries {
+			summary.WriteString(fmt.Sprintf(
+				"%2d. %-*s %8d tokens %6s  (%s)\n",
+				i+1, pathWidth, e.path, e.tokens, formatShare(e.tokens, stats.EstimatedTokens), e.detail,
+			))
+		}
+	}
+	return summary.String()
+}
+
+func formatShare(tokens, total int) string {
+	if total == 0 {
+		return "0.0%"
+	}
+	return fmt.Sprintf("%.1f%%", float64(tokens)*100/float64(total))
+}
+
 func formatTokenCount(tokens int) string {
 	if tokens < 100 {
 		return fmt.Sprintf("%d", tokens)
@@ -73,20 +170,26 @@ func formatTokenCount(tokens int) string {
 
 func SortFileList(files []FileInfo) []FileInfo {
 	sort.Slice(files, func(i, j int) bool {
-		// Split the paths into components
-		pathI := strings.Split(files[i].Path, "/")
-		pathJ := strings.Split(files[j].Path, "/")
-
-		// Compare each component
-		for k := 0; k < len(pathI) && k < len(pathJ); k++ {
-			if pathI[k] != pathJ[k] {
-				return pathI[k] < pathJ[k]
-			}
-		}
-
-		// If all components are the same up to this point, shorter path comes first
-		return len(pathI) < len(pathJ)
+		return pathLess(files[i].Path, files[j].Path)
 	})
 
 	return files
 }
+
+// pathLess orders paths directory by directory, so the files of a
+// directory stay together
+func pathLess(a, b string) bool {
+	// Split the paths into components
+	pathA := strings.Split(a, "/")
+	pathB := strings.Split(b, "/")
+
+	// Compare each component
+	for k := 0; k < len(pathA) && k < len(pathB); k++ {
+		if pathA[k] != pathB[k] {
+			return pathA[k] < pathB[k]
+		}
+	}
+
+	// If all components are the same up to this point, shorter path comes first
+	return len(pathA) < len(pathB)
+}
@@ -0,0 +1,208 @@
+package src
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/afero"
+)
+
+const TemplateExtension = ".tmpl"
+
+// PromptTemplate wraps the dumped files into a complete prompt, with
+// instructions before and after the code
+type PromptTemplate struct {
+	Name     string
+	template *template.Template
+}
+
+// TemplateData is what a prompt template can refer to. Without a
+// template, the same output is cut into parts from its Chunks.
+type TemplateData struct {
+	// Dump is the formatted files, as they are copied without a template
+	Dump     string
+	Files    []FileInfo
+	Tree     string
+	Stats    Stats
+	Question string
+
+	formattedFiles []string
+	formattedTree  string
+	position       PromptPosition
+}
+
+// ErrUnknownTemplate is returned when --template names a template that
+// is neither in a config file nor in a templates directory
+type ErrUnknownTemplate struct {
+	Name     string
+	Searched []string
+}
+
+func (e ErrUnknownTemplate) Error() string {
+	return fmt.Sprintf("unknown template: %s (looked in the config file and %s)", e.Name, strings.Join(e.Searched, ", "))
+}
+
+var templateFuncs = template.FuncMap{
+	"language": func(file FileInfo) string { return file.Language },
+}
+
+func ParsePromptTemplate(name, text string) (*PromptTemplate, error) {
+	parsed, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing template %s: %w", name, err)
+	}
+	return &PromptTemplate{Name: name, template: parsed}, nil
+}
+
+func (pt *PromptTemplate) Render(data TemplateData) (string, error) {
+	var b strings.Builder
+	if err := pt.template.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("rendering template %s: %w", pt.Name, err)
+	}
+	return b.String(), nil
+}
+
+// ReadQuestion returns the instructions for the model: the prompt file
+// followed by the --ask question. Either can be "-" to read stdin.
+func ReadQuestion(config Config, runConfig RunConfig) (string, error) {
+	var sections []string
+	if config.PromptFile != "" {
+		text, err := readPromptFile(config.PromptFile, runConfig)
+		if err != nil {
+			return "", err
+		}
+		sections = append(sections, text)
+	}
+	if config.Question != "" {
+		text := config.Question
+		if text == "-" {
+			var err error
+			if text, err = readPromptFile("-", runConfig); err != nil {
+				return "", err
+			}
+		}
+		sections = append(sections, strings.TrimSpace(text))
+	}
+	return strings.TrimSpace(strings.Join(sections, "\n\n")), nil
+}
+
+func readPromptFile(path string, runConfig RunConfig) (string, error) {
+	if path != "-" {
+		data, err := afero.ReadFile(runConfig.Fs, path)
+		if err != nil {
+			return "", fmt.Errorf("reading prompt file: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	if runConfig.Stdin == nil {
+		return "", fmt.Errorf("reading the question from stdin: no input")
+	}
+	data, err := io.ReadAll(runConfig.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("reading the question from stdin: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// NewTemplateData collects what a template can use from the files that
+// made it into the output
+func NewTemplateData(stats Stats, formatter Formatter, tree string, config Config) TemplateData {
+	var files []FileInfo
+	for _, fileInfo := range stats.ProcessedFiles {
+		if fileInfo.Status == StatusSkippedOverBudget {
+			continue
+		}
+		files = append(files, fileInfo)
+	}
+	data := TemplateData{
+		Files:    files,
+		Tree:     tree,
+		Stats:    stats,
+		Question: config.Question,
+		position: config.PromptPosition,
+	}
+	// A tree-only dump leaves the contents out
+	if !config.TreeOnly {
+		data.formattedFiles = FormatFiles(stats, formatter)
+		data.Dump = strings.Join(data.formattedFiles, "")
+	}
+	if config.Tree {
+		data.formattedTree = formatter.FormatTree(tree)
+	}
+	return data
+}
+
+// Chunks returns the output without a template in the pieces that stay
+// together when it is split: the question when it goes first, each line
+// of the tree, each file, and the question when it goes last
+func (d TemplateData) Chunks() []string {
+	var chunks []string
+	if d.Question != "" && d.position == PromptBefore {
+		chunks = append(chunks, d.Question+"\n\n")
+	}
+	if d.formattedTree != "" {
+		// A tree-only dump of a large project is too long for one part.
+		// The formatted tree ends in a newline, so the last line is empty.
+		lines := strings.SplitAfter(d.formattedTree, "\n")
+		chunks = append(chunks, lines[:len(lines)-1]...)
+	}
+	chunks = append(chunks, d.formattedFiles...)
+	if d.Question != "" && d.position != PromptBefore {
+		chunks = append(chunks, d.Question+"\n")
+	}
+	return chunks
+}
+
+// LoadPromptTemplate returns the template the config names, or nil when
+// there is none. A name is looked up in the config files' templates,
+// then as a file path, then as <name>.tmpl in the templates directories.
+func (cl *ConfigLoader) LoadPromptTemplate(config Config) (*PromptTemplate, error) {
+	name := config.Template
+	if name == "" {
+		return nil, nil
+	}
+
+	if text, ok := config.Templates[name]; ok {
+		return ParsePromptTemplate(name, text)
+	}
+
+	var candidates []string
+	if strings.ContainsRune(name, filepath.Separator) || strings.HasSuffix(name, TemplateExtension) {
+		candidates = []string{name}
+	} else {
+		for _, dir := range cl.TemplateDirs() {
+			candidates = append(candidates, filepath.Join(dir, name+TemplateExtension))
+		}
+	}
+	for _, path := range candidates {
+		if exists, _ := afero.Exists(cl.fs, path); !exists {
+			continue
+		}
+		text, err := afero.ReadFile(cl.fs, path)
+		if err != nil {
+			return nil, fmt.Errorf("reading template %s: %w", path, err)
+		}
+		return ParsePromptTemplate(name, string(text))
+	}
+	return nil, ErrUnknownTemplate{Name: name, Searched: candidates}
+}
+
+// TemplateDirs are the directories searched for templates, nearest
+// first: .dump_dir/templates next to each project config file, then
+// templates in the user's config directory
+func (cl *ConfigLoader) TemplateDirs() []string {
+	layers := cl.findConfigLayers()
+	dirs := make([]string, 0, len(layers))
+	for i := len(layers) - 1; i >= 0; i-- {
+		dir := filepath.Dir(layers[i].fsPath)
+		if layers[i].fsPath == cl.UserConfigPath {
+			dirs = append(dirs, filepath.Join(dir, "templates"))
+		} else {
+			dirs = append(dirs, filepath.Join(dir, ".dump_dir", "templates"))
+		}
+	}
+	return dirs
+}
@@ -13,6 +13,11 @@ func NewTokenEstimator() *TokenEstimator {
 	return &TokenEstimator{}
 }
 
+// CountTokens makes the estimator the default Tokenizer
+func (te *TokenEstimator) CountTokens(content string) int {
+	return te.EstimateTokens(content)
+}
+
 func (te *TokenEstimator) EstimateTokens(content string) int {
 	if content == "" {
 		return 0
@@ -0,0 +1,59 @@
+package src
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const DefaultTokenizer = "estimate"
+
+// Tokenizer counts the tokens a model sees for a piece of text. The
+// token budget, the output splitting and the stats all use it.
+type Tokenizer interface {
+	CountTokens(text string) int
+}
+
+var tokenizers = map[string]func() (Tokenizer, error){
+	"estimate":    func() (Tokenizer, error) { return NewTokenEstimator(), nil },
+	"cl100k_base": func() (Tokenizer, error) { return LoadBPETokenizer(Cl100kBase) },
+	"o200k_base":  func() (Tokenizer, error) { return LoadBPETokenizer(O200kBase) },
+}
+
+// ErrInvalidTokenizer is a custom error type for unknown tokenizers
+type ErrInvalidTokenizer struct {
+	Value string
+}
+
+func (e ErrInvalidTokenizer) Error() string {
+	return fmt.Sprintf("invalid tokenizer: %s (available: %s)", e.Value, strings.Join(TokenizerNames(), ", "))
+}
+
+// NewTokenizer returns the named tokenizer. BPE tokenizers load their
+// vocabulary the first time they are used.
+func NewTokenizer(name string) (Tokenizer, error) {
+	if name == "" {
+		name = DefaultTokenizer
+	}
+	newTokenizer, ok := tokenizers[name]
+	if !ok {
+		return nil, ErrInvalidTokenizer{Value: name}
+	}
+	return newTokenizer()
+}
+
+// IsTokenizerName reports whether name is a known tokenizer,
+// without loading its vocabulary
+func IsTokenizerName(name string) bool {
+	_, ok := tokenizers[name]
+	return ok
+}
+
+func TokenizerNames() []string {
+	names := make([]string, 0, len(tokenizers))
+	for name := range tokenizers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,150 @@
+package src
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TreeEntry is a path in a rendered tree, with an optional note
+// saying why it is not in the output
+type TreeEntry struct {
+	Path  string
+	IsDir bool
+	Note  string
+}
+
+type treeNode struct {
+	name     string
+	isDir    bool
+	note     string
+	children []*treeNode
+	byName   map[string]*treeNode
+}
+
+func newTreeNode(name string) *treeNode {
+	return &treeNode{name: name, byName: make(map[string]*treeNode)}
+}
+
+// RenderTree draws the entries as a directory tree like the tree
+// command, in the same order as the file list, with a slash after
+// directory names
+func RenderTree(entries []TreeEntry) string {
+	entries = append([]TreeEntry{}, entries...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return pathLess(entries[i].Path, entries[j].Path)
+	})
+
+	root := newTreeNode(".")
+	for _, entry := range entries {
+		node := root
+		for _, segment := range globSegments(entry.Path) {
+			child, ok := node.byName[segment]
+			if !ok {
+				child = newTreeNode(segment)
+				node.byName[segment] = child
+				node.children = append(node.children, child)
+			}
+			node = child
+		}
+		node.isDir = node.isDir || entry.IsDir
+		node.note = entry.Note
+	}
+
+	var b strings.Builder
+	b.WriteString(".\n")
+	root.render(&b, "")
+	return b.String()
+}
+
+func (n *treeNode) render(b *strings.Builder, indent string) {
+	for i, child := range n.children {
+		branch, nextIndent := "├── ", indent+"│   "
+		if i == len(n.children)-1 {
+			branch, nextIndent = "└── ", indent+"    "
+		}
+		name := child.name
+		if child.isDir || len(child.children) > 0 {
+			name += "/"
+		}
+		if child.note != "" {
+			name += " (" + child.note + ")"
+		}
+		b.WriteString(indent + branch + name + "\n")
+		child.render(b, nextIndent)
+	}
+}
+
+// BuildTree lists the files found for the dump, noting the ones whose
+// contents were left out. Decisions add the files and directories the
+// filters left out, when they were recorded, but not the files outside
+// the paths on the command line. With sizes, each file is noted with
+// its size, and the lines and tokens of the files that could be read.
+func BuildTree(stats Stats, decisions []Decision, sizes bool) []TreeEntry {
+	entries := make([]TreeEntry, 0, len(stats.ProcessedFiles))
+	found := make(map[string]bool)
+	for _, fileInfo := range stats.ProcessedFiles {
+		found[fileInfo.Path] = true
+		note := treeNote(fileInfo.Status)
+		if sizes {
+			note = treeSizeNote(fileInfo)
+		}
+		entries = append(entries, TreeEntry{Path: fileInfo.Path, Note: note})
+	}
+
+	for _, decision := range mergeDecisions(decisions) {
+		if decision.Included || decision.OutOfScope || found[decision.Path] {
+			continue
+		}
+		note := "ignored"
+		if decision.IsDir {
+			note = "skipped"
+		}
+		entries = append(entries, TreeEntry{Path: decision.Path, IsDir: decision.IsDir, Note: note})
+	}
+	return entries
+}
+
+func treeNote(status FileStatus) string {
+	switch status {
+	case StatusParsed:
+		return ""
+	case StatusSkippedBinary:
+		return "binary, not shown"
+	case StatusSkippedTooLarge:
+		return "too large, not shown"
+	case StatusSkippedOverBudget:
+		return "over the token budget, not shown"
+	default:
+		return "not shown"
+	}
+}
+
+// treeSizeNote describes a file in a tree without contents, where
+// nothing is shown and only the size tells the files apart
+func treeSizeNote(fileInfo FileInfo) string {
+	size := formatBytes(fileInfo.Size)
+	switch fileInfo.Status {
+	case StatusParsed, StatusSkippedOverBudget:
+		// The lines and tokens of a selected region are counted from the
+		// region, so its size is too
+		if fileInfo.Region != "" {
+			return fmt.Sprintf("%s: %s, %s, %s", fileInfo.Region, pluralize(fileInfo.Lines, "line"),
+				pluralize(fileInfo.Tokens, "token"), formatBytes(int64(len(fileInfo.Contents))))
+		}
+		return fmt.Sprintf("%s, %s, %s", pluralize(fileInfo.Lines, "line"), pluralize(fileInfo.Tokens, "token"), size)
+	case StatusSkippedBinary:
+		return "binary, " + size
+	case StatusSkippedTooLarge:
+		return "too large, " + size
+	default:
+		return size
+	}
+}
+
+func pluralize(count int, noun string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, noun)
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
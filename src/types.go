@@ -3,32 +3,165 @@ package src
 import (
 	"fmt"
 	"github.com/spf13/afero"
+	"io"
+	"time"
 )
 
 type FileStatus string
 
 const (
-	StatusParsed          FileStatus = "PARSED"
-	StatusSkippedBinary   FileStatus = "SKIPPED_BINARY"
-	StatusSkippedTooLarge FileStatus = "SKIPPED_TOO_LARGE"
+	StatusParsed            FileStatus = "PARSED"
+	StatusSkippedBinary     FileStatus = "SKIPPED_BINARY"
+	StatusSkippedTooLarge   FileStatus = "SKIPPED_TOO_LARGE"
+	StatusSkippedOverBudget FileStatus = "SKIPPED_OVER_BUDGET"
+)
+
+// PromptPosition is where the question goes when there is no template
+type PromptPosition string
+
+const (
+	PromptAfter  PromptPosition = "after"
+	PromptBefore PromptPosition = "before"
+)
+
+// DiffMode controls whether files are dumped as contents, diffs or both
+type DiffMode string
+
+const (
+	DiffModeNone DiffMode = ""
+	DiffModeOnly DiffMode = "diff"
+	DiffModeFull DiffMode = "full"
 )
 
 type FileInfo struct {
 	Path     string
 	Contents string
-	Status   FileStatus
+	// Diff is the unified diff against the diff base, when diffs were requested
+	Diff    string
+	Status  FileStatus
+	Size    int64
+	ModTime time.Time
+	// Lines and Tokens are filled in for parsed files by CalculateStats
+	Lines  int
+	Tokens int
+	// Region describes the lines selected from the file, when only
+	// part of it was asked for
+	Region string
+	// Language is detected from the whole file before its lines are
+	// selected or numbered, which would hide a shebang line
+	Language string
 }
 
+func (f FileInfo) with(status FileStatus, contents string) FileInfo {
+	f.Status = status
+	f.Contents = contents
+	return f
+}
+
+// Config is the effective configuration of a run. The JSON names are
+// part of the report format, so they must not change. Fields that only
+// matter inside a run, or that can hold a lot of text like the
+// question, are left out of it.
 type Config struct {
-	Action         string
-	Extensions     []string
-	Directories    []string
-	SkipDirs       []string
-	SpecificFiles  []string
-	IncludeIgnored bool
-	MaxFileSize    int64
-	GlobPatterns   []string
-	NoConfig       bool
+	Action string `json:"-"`
+	// ConfigFiles are the files to check with dump_dir config validate
+	ConfigFiles []string `json:"-"`
+	// Force lets dump_dir init overwrite an existing config file
+	Force          bool           `json:"-"`
+	Extensions     []string       `json:"extensions"`
+	Directories    []string       `json:"directories"`
+	SkipDirs       []string       `json:"skip_dirs"`
+	SpecificFiles  []string       `json:"specific_files"`
+	IncludeIgnored bool           `json:"include_ignored"`
+	MaxFileSize    int64          `json:"max_filesize"`
+	GlobPatterns   []string       `json:"globs"`
+	NoConfig       bool           `json:"no_config"`
+	Profile        string         `json:"profile"`
+	Format         string         `json:"format"`
+	Tokenizer      string         `json:"tokenizer"`
+	MaxTokens      int            `json:"max_tokens"`
+	Top            int            `json:"top"`
+	SplitTokens    int            `json:"split_tokens"`
+	SplitSize      int64          `json:"split_size"`
+	SplitToFiles   bool           `json:"split_files"`
+	OutputFile     string         `json:"output"`
+	Stdout         bool           `json:"stdout"`
+	GitChanged     bool           `json:"git_changed"`
+	GitStaged      bool           `json:"git_staged"`
+	GitSince       string         `json:"git_since"`
+	Diff           DiffMode       `json:"diff"`
+	DiffBase       string         `json:"diff_base"`
+	DryRun         bool           `json:"-"`
+	Report         string         `json:"report"`
+	ReportFile     string         `json:"report_file"`
+	Template       string         `json:"template"`
+	Question       string         `json:"-"`
+	Tree           bool           `json:"tree"`
+	TreeIgnored    bool           `json:"tree_ignored"`
+	TreeOnly       bool           `json:"tree_only"`
+	LineNumbers    bool           `json:"line_numbers"`
+	PromptFile     string         `json:"prompt_file"`
+	PromptPosition PromptPosition `json:"prompt_position"`
+	// Selections are the regions asked for in files given with a
+	// selector like main.go:40-120 or run.go#performDumpDir
+	Selections map[string][]FileSelector `json:"-"`
+	// FlagsGiven are the long names of the options given on the command
+	// line, so a value that equals the default still wins over the
+	// config files
+	FlagsGiven map[string]bool `json:"-"`
+	// Warnings are the paths that could not be added, printed once it
+	// is known where messages go
+	Warnings []string `json:"-"`
+	// Templates are the templates defined in config files, by name
+	Templates map[string]string `json:"-"`
+	// PriorityPaths are the config file include entries, which are
+	// kept first when trimming the output to fit MaxTokens
+	PriorityPaths []string `json:"-"`
+	// IgnorePaths are the config file ignore entries, to tell them
+	// apart from --skip when explaining why a file was left out
+	IgnorePaths []string `json:"-"`
+	// IncludeGlobs are the config file include entries that are glob
+	// patterns. They are priorities too.
+	IncludeGlobs []string `json:"-"`
+}
+
+func (c *Config) setFlag(names ...string) {
+	if c.FlagsGiven == nil {
+		c.FlagsGiven = make(map[string]bool)
+	}
+	for _, name := range names {
+		c.FlagsGiven[name] = true
+	}
+}
+
+// FlagGiven reports whether an option was given on the command line,
+// e.g. FlagGiven("stdout") for --stdout or --no-stdout
+func (c Config) FlagGiven(name string) bool {
+	return c.FlagsGiven[name]
+}
+
+// turnOff handles --no-<switch>, which turns off a switch the config
+// files turn on. Turning off the tree also turns off its variants.
+func (c *Config) turnOff(name string) {
+	switches := map[string]*bool{
+		"include-ignored": &c.IncludeIgnored,
+		"split-files":     &c.SplitToFiles,
+		"stdout":          &c.Stdout,
+		"git-changed":     &c.GitChanged,
+		"git-staged":      &c.GitStaged,
+		"tree":            &c.Tree,
+		"tree-ignored":    &c.TreeIgnored,
+		"tree-only":       &c.TreeOnly,
+		"line-numbers":    &c.LineNumbers,
+	}
+	names := []string{name}
+	if name == "tree" {
+		names = append(names, "tree-ignored", "tree-only")
+	}
+	for _, name := range names {
+		*switches[name] = false
+		c.setFlag(name)
+	}
 }
 
 func (c *Config) AddSkipDir(path string) {
@@ -52,7 +185,27 @@ func (c *Config) AddIncludePath(path string) error {
 	if path == "" {
 		return nil
 	}
+	path, selector, err := ParseFileSelector(path)
+	if err != nil {
+		return err
+	}
 	normalizedPath := NormalizePath(path)
+	// A file given whole and with a selector is dumped whole, whichever
+	// comes first
+	givenWhole := contains(c.SpecificFiles, normalizedPath) && len(c.Selections[normalizedPath]) == 0
+	switch {
+	case selector != nil && givenWhole:
+		return nil
+	case selector != nil:
+		if err := c.addSelection(normalizedPath, *selector); err != nil {
+			return err
+		}
+	case len(c.Selections[normalizedPath]) > 0:
+		delete(c.Selections, normalizedPath)
+		if len(c.Selections) == 0 {
+			c.Selections = nil
+		}
+	}
 
 	// Check if path is in skip directories
 	for _, skipDir := range c.SkipDirs {
@@ -89,20 +242,64 @@ func (c *Config) AddIncludePath(path string) error {
 	return nil
 }
 
+// addIncludePathOrWarn adds a path, keeping a warning when it cannot be
+// added. It reports whether the path was added.
+func (c *Config) addIncludePathOrWarn(path string) bool {
+	if err := c.AddIncludePath(path); err != nil {
+		c.warnAboutPath(path, err)
+		return false
+	}
+	return true
+}
+
+func (c *Config) warnAboutPath(path string, err error) {
+	c.Warnings = append(c.Warnings, fmt.Sprintf("Warning: Could not process path %s: %v", path, err))
+}
+
+// addSelection records a selector for a file, so only the selected
+// regions of it are dumped
+func (c *Config) addSelection(path string, selector FileSelector) error {
+	if isDir, err := isDirectory(path); err == nil && isDir {
+		return ErrInvalidSelector{Value: path + selector.String(), Reason: "only files can be selected from"}
+	}
+	if c.Selections == nil {
+		c.Selections = make(map[string][]FileSelector)
+	}
+	for _, existing := range c.Selections[path] {
+		if existing == selector {
+			return nil
+		}
+	}
+	c.Selections[path] = append(c.Selections[path], selector)
+	return nil
+}
+
+// DiagnosticsToStderr reports whether summaries and warnings have to
+// stay out of stdout, because file contents or the report are written
+// there or to a file
+func (c *Config) DiagnosticsToStderr() bool {
+	return c.Stdout || c.OutputFile != "" || (c.Report != "" && c.ReportFile == "")
+}
+
 type RunConfig struct {
 	Fs        afero.Fs
 	Clipboard ClipboardManager
-	Version   string
-	Commit    string
-	Date      string
+	Stdin     io.Reader
+	// Stdout and Stderr default to the process's own
+	Stdout  io.Writer
+	Stderr  io.Writer
+	Version string
+	Commit  string
+	Date    string
 }
 
 type Stats struct {
-	TotalFiles      int
-	TotalLines      int
-	EstimatedTokens int
-	ProcessedFiles  []FileInfo
-	ParsedFiles     []FileInfo
-	SkippedLarge    []FileInfo
-	SkippedBinary   []FileInfo
+	TotalFiles        int
+	TotalLines        int
+	EstimatedTokens   int
+	ProcessedFiles    []FileInfo
+	ParsedFiles       []FileInfo
+	SkippedLarge      []FileInfo
+	SkippedBinary     []FileInfo
+	SkippedOverBudget []FileInfo
 }
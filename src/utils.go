@@ -37,3 +37,9 @@ func NormalizePath(path string) string {
 	// Add ./ prefix
 	return "./" + cleaned
 }
+
+// isWithin reports whether path is dir or lies below it. Both paths
+// must already be normalized.
+func isWithin(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
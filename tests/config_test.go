@@ -2,6 +2,7 @@ package tests
 
 import (
 	"github.com/fargusplumdoodle/dump_dir/tests/e2e"
+	"strings"
 	"testing"
 )
 
@@ -186,4 +187,178 @@ ignore:
 			AssertFileInOutput("./extra.txt").
 			AssertFileCount(6)
 	})
+
+	t.Run("config file options apply like command line options", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				".dump_dir.yml": `---
+extensions: [go]
+format: xml
+stdout: true`,
+				"./src/main.go": "package main\nfunc main() {}\n",
+				"./README.md":   "# Test Project\n",
+			}).
+			WithArgs(".")
+
+		result := env.Run()
+
+		result.AssertNoError()
+		if !strings.Contains(result.Stdout, `<document path="./src/main.go">`) {
+			t.Errorf("expected ./src/main.go in XML on stdout, got:\n%s", result.Stdout)
+		}
+		if strings.Contains(result.Stdout, "README.md") {
+			t.Errorf("README.md should be left out by the extensions option, got:\n%s", result.Stdout)
+		}
+		if !strings.Contains(result.Stderr, "written to stdout") {
+			t.Errorf("expected the summary on stderr, got:\n%s", result.Stderr)
+		}
+	})
+
+	t.Run("command line options beat the config file", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				".dump_dir.yml": `---
+extensions: [go]`,
+				"./src/main.go": "package main\nfunc main() {}\n",
+				"./README.md":   "# Test Project\n",
+			}).
+			WithArgs(". -e md")
+
+		result := env.Run()
+
+		validator := e2e.NewOutputValidator(t, result)
+		validator.
+			AssertSuccessfulRun().
+			AssertFileInOutput("./README.md").
+			AssertFileNotInOutput("./src/main.go")
+	})
+
+	t.Run("config file at the repository root applies in a subdirectory", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"../.git/HEAD": "ref: refs/heads/main\n",
+				"../.dump_dir.yml": `---
+include:
+  - ./README.md
+ignore:
+  - ./tests/vendor`,
+				"../README.md":    "# Test Project\n",
+				"./main.go":       "package main\nfunc main() {}\n",
+				"./vendor/lib.go": "package vendor\nfunc lib() {}\n",
+			}).
+			WithArgs(".")
+
+		result := env.Run()
+
+		validator := e2e.NewOutputValidator(t, result)
+		validator.
+			AssertSuccessfulRun().
+			AssertFileInOutput("../README.md").
+			AssertFileInOutput("./main.go").
+			AssertFileNotInOutput("./vendor/lib.go")
+	})
+
+	t.Run("typo in config file is reported with its position", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				".dump_dir.yml": `---
+include:
+  - ./src
+ignroe:
+  - ./vendor`,
+				"./src/main.go": "package main\nfunc main() {}\n",
+			}).
+			WithArgs(".")
+
+		result := env.Run()
+
+		result.AssertError()
+		if !strings.Contains(result.Err.Error(), `.dump_dir.yml:4:1: unknown key "ignroe" (did you mean "ignore"?)`) {
+			t.Errorf("unexpected error: %v", result.Err)
+		}
+	})
+
+	t.Run("config validate reports every problem", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				".dump_dir.yml": `---
+include:
+  - ./src
+  - ./prompts
+max_tokens: lots`,
+				"./src/main.go": "package main\nfunc main() {}\n",
+			}).
+			WithArgs("config validate")
+
+		result := env.Run()
+
+		result.AssertError().
+			AssertOutputContains(".dump_dir.yml:4:5: include: ./prompts does not exist").
+			AssertOutputContains(`.dump_dir.yml:5:13: max_tokens: expected a number, got "lots"`)
+	})
+
+	t.Run("config validate reports invalid values", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				".dump_dir.yml": `---
+format: jsonx
+max_filesize: huge`,
+				"./src/main.go": "package main\nfunc main() {}\n",
+			}).
+			WithArgs("config validate")
+
+		result := env.Run()
+
+		result.AssertError().
+			AssertOutputContains(".dump_dir.yml:2:9: format: invalid format: jsonx").
+			AssertOutputContains(".dump_dir.yml:3:15: max_filesize: invalid max filesize: huge")
+	})
+
+	t.Run("invalid value in config file is reported with its position", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				".dump_dir.yml": `---
+max_filesize: huge`,
+				"./src/main.go": "package main\nfunc main() {}\n",
+			}).
+			WithArgs("./src")
+
+		result := env.Run()
+
+		result.AssertError()
+		if !strings.Contains(result.Err.Error(), ".dump_dir.yml:2:15: max_filesize: invalid max filesize: huge") {
+			t.Errorf("unexpected error: %v", result.Err)
+		}
+	})
+
+	t.Run("config validate accepts a valid file", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"team.yml": `---
+include:
+  - ./src`,
+				"./src/main.go": "package main\nfunc main() {}\n",
+			}).
+			WithArgs("config validate team.yml")
+
+		result := env.Run()
+
+		result.AssertNoError().AssertOutputContains("✅ team.yml is valid")
+	})
+
+	t.Run("command line turns off a switch from the config file", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				".dump_dir.yml": `---
+line_numbers: true
+stdout: true`,
+				"./src/main.go": "package main\nfunc main() {}\n",
+			}).
+			WithArgs("./src --no-line-numbers --no-stdout")
+
+		result := env.Run()
+
+		result.AssertNoError().
+			AssertClipboardContains("START FILE: ./src/main.go\npackage main\nfunc main() {}\n")
+	})
 }
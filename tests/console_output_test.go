@@ -140,4 +140,74 @@ func TestConsoleOutput(t *testing.T) {
 			t.Error("Empty file should be listed in parsed files")
 		}
 	})
+
+	t.Run("test per-file lines, tokens and share", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./small.txt": "one two\n",
+				"./large.txt": strings.Repeat("word word word\n", 9),
+			}).
+			WithArgs(". --tokenizer estimate")
+
+		result := env.Run()
+
+		fileLine := regexp.MustCompile(`- (\S+)\s+(\d+) lines\s+(\d+) tokens\s+([\d.]+)%`)
+		matches := fileLine.FindAllStringSubmatch(result.Output, -1)
+		if len(matches) != 2 {
+			t.Fatalf("Expected a stats line for each file, got: %q", result.Output)
+		}
+
+		var lines, tokens int
+		var share float64
+		for _, match := range matches {
+			fileLines, _ := strconv.Atoi(match[2])
+			fileTokens, _ := strconv.Atoi(match[3])
+			fileShare, _ := strconv.ParseFloat(match[4], 64)
+			lines += fileLines
+			tokens += fileTokens
+			share += fileShare
+			if match[1] == "./large.txt" && fileLines != 9 {
+				t.Errorf("Expected 9 lines for large.txt, got %d", fileLines)
+			}
+		}
+		if lines != 10 {
+			t.Errorf("Expected per-file lines to add up to 10, got %d", lines)
+		}
+		if share < 99.8 || share > 100.2 {
+			t.Errorf("Expected shares to add up to 100%%, got %.1f", share)
+		}
+		if !strings.Contains(result.Output, "Estimated tokens: "+strconv.Itoa(tokens)) {
+			t.Errorf("Expected per-file tokens to add up to the total %d, got: %q", tokens, result.Output)
+		}
+	})
+
+	t.Run("test top files and directories", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./src/main.go":              "package main\n",
+				"./vendor/lib/huge.js":       strings.Repeat("var x = 1;\n", 200),
+				"./vendor/lib/also_large.js": strings.Repeat("var y = 2;\n", 100),
+				"./fixtures/data.json":       strings.Repeat("{\"a\": 1}\n", 50),
+			}).
+			WithArgs(". --top 2")
+
+		result := env.Run()
+
+		result.
+			AssertNoError().
+			AssertOutputContains("🏋️ Heaviest files:").
+			AssertOutputContains("🏋️ Heaviest directories:")
+
+		heaviestFile := regexp.MustCompile(`(?m)^ 1\. \./vendor/lib/huge\.js\s+\d+ tokens`)
+		if !heaviestFile.MatchString(result.Output) {
+			t.Errorf("Expected huge.js to be the heaviest file, got: %q", result.Output)
+		}
+		heaviestDir := regexp.MustCompile(`(?m)^ 1\. \./vendor\s+\d+ tokens\s+[\d.]+%\s+\(2 files\)`)
+		if !heaviestDir.MatchString(result.Output) {
+			t.Errorf("Expected ./vendor to be the heaviest directory, got: %q", result.Output)
+		}
+		if strings.Contains(result.Output, " 3. ") {
+			t.Error("Expected only the top 2 entries in each list")
+		}
+	})
 }
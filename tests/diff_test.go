@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"github.com/fargusplumdoodle/dump_dir/src"
+	"github.com/fargusplumdoodle/dump_dir/tests/e2e"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+const mainDiff = `diff --git a/src/main.go b/src/main.go
+--- a/src/main.go
++++ b/src/main.go
+@@ -1,2 +1,3 @@
+ package main
++// changed
+ func main() {}
+`
+
+func mockGitDiff(responses map[string]string) {
+	src.ExecCommand = func(name string, arg ...string) *exec.Cmd {
+		// %b expands the \0 separators without treating % in diffs as verbs
+		return exec.Command("printf", "%b", responses[strings.Join(arg, " ")])
+	}
+}
+
+func TestDiffMode(t *testing.T) {
+	defer func() { src.ExecCommand = exec.Command }()
+
+	files := map[string]string{
+		"./src/main.go":  "package main\n// changed\nfunc main() {}\n",
+		"./src/other.go": "package main\n",
+		"./src/logo.png": "\x00\x01\x02\x03",
+	}
+	mockGitDiff(map[string]string{
+		"merge-base HEAD HEAD": "abc123\n",
+		"diff --name-only -z --relative --diff-filter=d abc123": "src/main.go\\0src/logo.png\\0",
+		"diff abc123 -- ./src/main.go":                          mainDiff,
+	})
+
+	t.Run("diff replaces the file contents", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).WithFiles(files).WithArgs("--diff").Run()
+
+		result.
+			AssertNoError().
+			AssertClipboardContains("START DIFF: ./src/main.go\n" + mainDiff + "\nEND DIFF: ./src/main.go").
+			AssertClipboardContains("START FILE: ./src/logo.png\n<BINARY SKIPPED>").
+			AssertOutputContains("Total files found: 2")
+		if strings.Contains(result.Clipboard, "START FILE: ./src/main.go") {
+			t.Error("Expected only the diff of main.go")
+		}
+		if strings.Contains(result.Clipboard, "other.go") {
+			t.Error("Expected unchanged files to be left out")
+		}
+	})
+
+	t.Run("full diff has the contents before the diff", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).WithFiles(files).WithArgs("--diff-full --format markdown").Run()
+
+		result.
+			AssertNoError().
+			AssertClipboardContains("### ./src/main.go\n\n```go\npackage main\n// changed\nfunc main() {}\n```\n\n" +
+				"### ./src/main.go (diff)\n\n```diff\n" + mainDiff + "```\n\n")
+	})
+}
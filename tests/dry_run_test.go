@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"github.com/fargusplumdoodle/dump_dir/tests/e2e"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	files := map[string]string{
+		".gitignore":            "# build output\n*.log\n",
+		".dump_dir.yml":         "ignore:\n  - ./fixtures\n",
+		"./main.go":             "package main\n",
+		"./main_test.go":        "package main\n",
+		"./notes.md":            "# notes\n",
+		"./debug.log":           "log line\n",
+		"./vendor/lib.go":       "package lib\n",
+		"./fixtures/data.go":    "package fixtures\n",
+		"./generated/models.go": strings.Repeat("// generated\n", 200),
+	}
+
+	assertReason := func(t *testing.T, output, marker, path, reason string) {
+		t.Helper()
+		line := regexp.MustCompile(`(?m)^` + regexp.QuoteMeta(marker) + ` ` + regexp.QuoteMeta(path) + ` +` + regexp.QuoteMeta(reason) + `$`)
+		if !line.MatchString(output) {
+			t.Errorf("Expected %s %s with reason %q, got:\n%s", marker, path, reason, output)
+		}
+	}
+
+	t.Run("explains every decision", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs("ls . -e go -g !**/*_test.go --skip ./vendor --max-filesize 1KB").
+			Run()
+
+		result.AssertNoError()
+		assertReason(t, result.Stdout, "✅", "./main.go", "matches --extension go")
+		assertReason(t, result.Stdout, "❌", "./main_test.go", "excluded by --glob !**/*_test.go")
+		assertReason(t, result.Stdout, "❌", "./notes.md", "extension not in --extension go")
+		assertReason(t, result.Stdout, "❌", "./debug.log", "ignored by .gitignore:2 (*.log)")
+		assertReason(t, result.Stdout, "📁", "./vendor/", "--skip ./vendor")
+		assertReason(t, result.Stdout, "📁", "./fixtures/", "config ignore entry ./fixtures")
+		assertReason(t, result.Stdout, "⚠️", "./generated/models.go", "too large, only a placeholder is dumped (2600 bytes, --max-filesize is 1024)")
+		result.AssertOutputContains("2 files would be dumped, 5 files left out, 2 directories skipped")
+
+		if result.Clipboard != "" {
+			t.Error("Expected the clipboard not to be touched")
+		}
+	})
+
+	t.Run("dry-run flag lists the working directory", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs("--dry-run -g *.md").
+			Run()
+
+		result.AssertNoError()
+		assertReason(t, result.Stdout, "✅", "./notes.md", "matches --glob *.md")
+		assertReason(t, result.Stdout, "❌", "./main.go", "does not match --glob *.md")
+		if strings.Contains(result.Stdout, "START FILE") || result.Clipboard != "" {
+			t.Error("Expected no file contents in a dry run")
+		}
+	})
+}
@@ -2,6 +2,7 @@ package e2e
 
 type MockClipboard struct {
 	Content string
+	History []string
 }
 
 func NewMockClipboard() *MockClipboard {
@@ -10,5 +11,6 @@ func NewMockClipboard() *MockClipboard {
 
 func (m *MockClipboard) WriteAll(text string) error {
 	m.Content = text
+	m.History = append(m.History, text)
 	return nil
 }
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -22,6 +23,7 @@ type Environment struct {
 	originalWd string
 	currentWd  string
 	args       []string
+	stdin      string
 }
 
 // NewEnvironment creates a new test environment with mocked dependencies
@@ -50,6 +52,12 @@ func (e *Environment) WithArgs(args string) *Environment {
 	return e
 }
 
+// WithStdin sets the text the command reads from standard input
+func (e *Environment) WithStdin(stdin string) *Environment {
+	e.stdin = stdin
+	return e
+}
+
 // WithFiles creates files in the virtual filesystem
 func (e *Environment) WithFiles(files map[string]string) *Environment {
 	for path, content := range files {
@@ -80,33 +88,50 @@ func (e *Environment) Run() *Result {
 	originalStdout := os.Stdout
 	originalStderr := os.Stderr
 
-	// Create pipe for capturing Output
-	r, w, _ := os.Pipe()
-	os.Stdout = w
-	os.Stderr = w
+	// Create pipes for capturing stdout and stderr separately
+	stdoutReader, stdoutWriter, _ := os.Pipe()
+	stderrReader, stderrWriter, _ := os.Pipe()
+	os.Stdout = stdoutWriter
+	os.Stderr = stderrWriter
+
+	// Drain the pipes while the command runs so large outputs cannot block it
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		io.Copy(e.stdout, stdoutReader)
+	}()
+	go func() {
+		defer wg.Done()
+		io.Copy(e.stderr, stderrReader)
+	}()
 
 	// Run the command
 	err := src.Run(e.args, src.RunConfig{
 		Fs:        e.fs,
 		Clipboard: e.clipboard,
+		Stdin:     strings.NewReader(e.stdin),
+		Stdout:    stdoutWriter,
+		Stderr:    stderrWriter,
 		Version:   "test",
 		Commit:    "test",
 		Date:      "test",
 	})
 
 	// Restore original stdout/stderr
-	w.Close()
+	stdoutWriter.Close()
+	stderrWriter.Close()
 	os.Stdout = originalStdout
 	os.Stderr = originalStderr
-
-	// Capture Output
-	var buf bytes.Buffer
-	io.Copy(&buf, r)
+	wg.Wait()
 
 	return &Result{
-		env:       e,
-		Output:    buf.String(),
-		Clipboard: e.clipboard.Content,
-		Err:       err,
+		env:              e,
+		Output:           e.stdout.String() + e.stderr.String(),
+		Stdout:           e.stdout.String(),
+		Stderr:           e.stderr.String(),
+		Clipboard:        e.clipboard.Content,
+		ClipboardHistory: e.clipboard.History,
+		Err:              err,
 	}
 }
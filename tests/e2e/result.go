@@ -2,15 +2,19 @@ package e2e
 
 import (
 	"fmt"
+	"github.com/spf13/afero"
 	"strings"
 )
 
 // Result contains the results of running the command
 type Result struct {
-	env       *Environment
-	Output    string
-	Clipboard string
-	Err       error
+	env              *Environment
+	Output           string
+	Stdout           string
+	Stderr           string
+	Clipboard        string
+	ClipboardHistory []string
+	Err              error
 }
 
 // AssertOutputContains checks if the command Output contains expected content
@@ -29,6 +33,28 @@ func (r *Result) AssertClipboardContains(expected string) *Result {
 	return r
 }
 
+// AssertFileContains checks if a file written by the command contains expected content
+func (r *Result) AssertFileContains(path, expected string) *Result {
+	content, err := afero.ReadFile(r.env.fs, path)
+	if err != nil {
+		r.env.t.Errorf("Expected file %q to be written: %v", path, err)
+		return r
+	}
+	if !strings.Contains(string(content), expected) {
+		r.env.t.Errorf("Expected file %q to contain %q, got: %q", path, expected, string(content))
+	}
+	return r
+}
+
+// ReadFile returns the contents of a file written by the command
+func (r *Result) ReadFile(path string) string {
+	content, err := afero.ReadFile(r.env.fs, path)
+	if err != nil {
+		r.env.t.Fatalf("Expected file %q to be written: %v", path, err)
+	}
+	return string(content)
+}
+
 // AssertNoError checks if the command completed without error
 func (r *Result) AssertNoError() *Result {
 	if r.Err != nil {
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"github.com/fargusplumdoodle/dump_dir/tests/e2e"
+	"testing"
+)
+
+func TestOutputFormats(t *testing.T) {
+	t.Run("plain format is the default", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./main.go": "package main\n",
+			}).
+			WithArgs(".")
+
+		result := env.Run()
+
+		validator := e2e.NewOutputValidator(t, result)
+		validator.
+			AssertSuccessfulRun().
+			AssertWholeFileContent("./main.go", "package main\n")
+	})
+
+	t.Run("xml format wraps files in document tags", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./main.go": "package main\n",
+			}).
+			WithArgs(". --format xml")
+
+		result := env.Run()
+
+		result.
+			AssertNoError().
+			AssertClipboardContains("<document path=\"./main.go\">\n<source>\npackage main\n</source>\n</document>\n")
+	})
+
+	t.Run("markdown format uses headings and fenced code blocks", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./notes.txt": "hello\n",
+			}).
+			WithArgs(". -f markdown")
+
+		result := env.Run()
+
+		result.
+			AssertNoError().
+			AssertClipboardContains("### ./notes.txt\n\n```text\nhello\n```\n")
+	})
+
+	t.Run("markdown fence is longer than fences inside the file", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./notes.txt": "```\ncode\n```\n",
+			}).
+			WithArgs(". -f markdown")
+
+		result := env.Run()
+
+		result.
+			AssertNoError().
+			AssertClipboardContains("````text\n```\ncode\n```\n````\n")
+	})
+
+	t.Run("markdown code blocks are tagged with the file language", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./main.go":    "package main\n",
+				"./Dockerfile": "FROM scratch\n",
+				"./run":        "#!/usr/bin/env python3\nprint('hi')\n",
+			}).
+			WithArgs(". -f markdown")
+
+		result := env.Run()
+
+		result.
+			AssertNoError().
+			AssertClipboardContains("### ./main.go\n\n```go\n").
+			AssertClipboardContains("### ./Dockerfile\n\n```dockerfile\n").
+			AssertClipboardContains("### ./run\n\n```py\n")
+	})
+
+	t.Run("unknown format is an error", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./main.go": "package main\n",
+			}).
+			WithArgs(". --format yaml")
+
+		result := env.Run()
+
+		result.AssertError()
+	})
+}
@@ -122,4 +122,97 @@ func TestGlobOptions(t *testing.T) {
 			AssertFileNotInOutput("./src/utils/test_util.go").
 			AssertFileCount(4)
 	})
+
+	t.Run("Recursive glob matches directory paths", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./src/api/handlers/user.go":   "package handlers\n",
+				"./src/handlers/health.go":     "package handlers\n",
+				"./src/api/handlers/user.md":   "# user\n",
+				"./src/api/middleware/auth.go": "package middleware\n",
+				"./cmd/handlers/run.go":        "package handlers\n",
+			}).
+			WithArgs("-g src/**/handlers/*.go .")
+
+		result := env.Run()
+
+		validator := e2e.NewOutputValidator(t, result)
+		validator.
+			AssertSuccessfulRun().
+			AssertFileInOutput("./src/api/handlers/user.go").
+			AssertFileInOutput("./src/handlers/health.go").
+			AssertFileNotInOutput("./src/api/handlers/user.md").
+			AssertFileNotInOutput("./src/api/middleware/auth.go").
+			AssertFileNotInOutput("./cmd/handlers/run.go").
+			AssertFileCount(2)
+	})
+
+	t.Run("Exclusion glob with extension filter", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./main.go":          "package main\n",
+				"./main_test.go":     "package main\n",
+				"./pkg/util.go":      "package pkg\n",
+				"./pkg/util_test.go": "package pkg\n",
+				"./pkg/README.md":    "# pkg\n",
+			}).
+			WithArgs("-e go -g !**/*_test.go .")
+
+		result := env.Run()
+
+		validator := e2e.NewOutputValidator(t, result)
+		validator.
+			AssertSuccessfulRun().
+			AssertFileInOutput("./main.go").
+			AssertFileInOutput("./pkg/util.go").
+			AssertFileNotInOutput("./main_test.go").
+			AssertFileNotInOutput("./pkg/util_test.go").
+			AssertFileNotInOutput("./pkg/README.md").
+			AssertFileCount(2)
+	})
+
+	t.Run("Inclusion and exclusion globs together", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./src/app.ts":              "export {}\n",
+				"./src/generated/schema.ts": "export {}\n",
+				"./src/app.test.ts":         "export {}\n",
+				"./src/styles.css":          "body {}\n",
+			}).
+			WithArgs("-g **/*.ts -g !src/generated -g !*.test.ts .")
+
+		result := env.Run()
+
+		validator := e2e.NewOutputValidator(t, result)
+		validator.
+			AssertSuccessfulRun().
+			AssertFileInOutput("./src/app.ts").
+			AssertFileNotInOutput("./src/generated/schema.ts").
+			AssertFileNotInOutput("./src/app.test.ts").
+			AssertFileNotInOutput("./src/styles.css").
+			AssertFileCount(1)
+	})
+
+	t.Run("Globs in the config file", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				".dump_dir.yml":          "include:\n  - docs/**/*.md\nignore:\n  - \"**/*.snap\"\n",
+				"./src/main.go":          "package main\n",
+				"./src/main.snap":        "snapshot\n",
+				"./docs/api/overview.md": "# API\n",
+				"./docs/notes.txt":       "notes\n",
+			}).
+			WithArgs("./src")
+
+		result := env.Run()
+
+		validator := e2e.NewOutputValidator(t, result)
+		validator.
+			AssertSuccessfulRun().
+			AssertFileInOutput("./src/main.go").
+			AssertFileInOutput("./docs/api/overview.md").
+			AssertFileNotInOutput("./src/main.snap").
+			AssertFileNotInOutput("./docs/notes.txt").
+			AssertFileCount(2)
+	})
 }
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fargusplumdoodle/dump_dir/src"
+	"github.com/fargusplumdoodle/dump_dir/tests/e2e"
+)
+
+func TestInit(t *testing.T) {
+	t.Run("writes a config file for the project", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./README.md":                   "# Test Project\n",
+				"./prompts/style.md":            "Use tabs.\n",
+				"./src/main.go":                 "package main\nfunc main() {}\n",
+				"./src/util.go":                 "package main\nfunc util() {}\n",
+				"./node_modules/left-pad/index": "module.exports = 1\n",
+				"./build/out.go":                "package out\n",
+				"./assets/logo.png":             strings.Repeat("\x89PNG\x00", 300*1024),
+			}).
+			WithArgs("init")
+
+		result := env.Run()
+
+		result.AssertNoError().
+			AssertOutputContains("✅ Wrote .dump_dir.yml with 3 ignore entries")
+		config := result.ReadFile(".dump_dir.yml")
+		for _, expected := range []string{
+			"# Files found by language: go (2), markdown (2)",
+			"  - ./README.md\n",
+			"  - ./prompts\n",
+			"  - ./assets       # 1.5 MB, mostly binary or large files\n",
+			"  - ./build        # build output\n",
+			"  - ./node_modules # installed packages\n",
+		} {
+			if !strings.Contains(config, expected) {
+				t.Errorf("expected config to contain %q, got:\n%s", expected, config)
+			}
+		}
+		if _, err := src.DecodeConfigFile(".dump_dir.yml", []byte(config)); err != nil {
+			t.Errorf("generated config is invalid: %v", err)
+		}
+	})
+
+	t.Run("leaves git ignored directories to .gitignore", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				".gitignore":                    "node_modules/\n",
+				"./src/main.go":                 "package main\nfunc main() {}\n",
+				"./node_modules/left-pad/index": "module.exports = 1\n",
+			}).
+			WithArgs("init --stdout")
+
+		result := env.Run()
+
+		result.AssertNoError()
+		if strings.Contains(result.Stdout, "node_modules") {
+			t.Errorf("expected node_modules to be left out, got:\n%s", result.Stdout)
+		}
+		if !strings.Contains(result.Stdout, "  # - ./prompts\n") {
+			t.Errorf("expected a commented prompts include, got:\n%s", result.Stdout)
+		}
+	})
+
+	t.Run("does not overwrite an existing config file", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				".dump_dir.yml": "include:\n  - ./src\n",
+				"./src/main.go": "package main\nfunc main() {}\n",
+			}).
+			WithArgs("init")
+
+		result := env.Run()
+
+		result.AssertError()
+		if result.ReadFile(".dump_dir.yml") != "include:\n  - ./src\n" {
+			t.Error("expected the existing config file to be kept")
+		}
+	})
+
+	t.Run("overwrites an existing config file with --force", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				".dump_dir.yml": "include:\n  - ./src\n",
+				"./src/main.go": "package main\nfunc main() {}\n",
+			}).
+			WithArgs("init --force")
+
+		result := env.Run()
+
+		result.AssertNoError().
+			AssertFileContains(".dump_dir.yml", "written by dump_dir init")
+	})
+}
@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/fargusplumdoodle/dump_dir/tests/e2e"
+)
+
+func TestLineNumbers(t *testing.T) {
+	tenLines := strings.Repeat("x\n", 9) + "last\n"
+
+	t.Run("plain format numbers every line", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./main.go": tenLines,
+			}).
+			WithArgs(". --line-numbers").
+			Run()
+
+		result.
+			AssertNoError().
+			AssertClipboardContains("START FILE: ./main.go\n 1 | x\n 2 | x\n").
+			AssertClipboardContains(" 9 | x\n10 | last\n\nEND FILE: ./main.go\n")
+	})
+
+	t.Run("xml format", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./main.go": "package main\n",
+			}).
+			WithArgs(". --line-numbers --format xml").
+			Run()
+
+		result.
+			AssertNoError().
+			AssertClipboardContains("<source>\n1 | package main\n</source>\n")
+	})
+
+	t.Run("markdown format", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./notes.txt": "hello\nworld\n",
+			}).
+			WithArgs(". --line-numbers -f markdown").
+			Run()
+
+		result.
+			AssertNoError().
+			AssertClipboardContains("```text\n1 | hello\n2 | world\n```\n")
+	})
+
+	t.Run("markdown format detects a shebang before numbering", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./bin/run": "#!/usr/bin/env python3\nprint('hi')\n",
+			}).
+			WithArgs("./bin --line-numbers -f markdown").
+			Run()
+
+		result.
+			AssertNoError().
+			AssertClipboardContains("```py\n1 | #!/usr/bin/env python3\n2 | print('hi')\n```\n")
+	})
+
+	t.Run("markdown format detects a shebang outside the selected lines", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./bin/run": "#!/usr/bin/env python3\nprint('hi')\n",
+			}).
+			WithArgs("./bin/run:2 -f markdown").
+			Run()
+
+		result.
+			AssertNoError().
+			AssertClipboardContains("```py\nprint('hi')\n```\n")
+	})
+
+	t.Run("tokens include the line numbers", func(t *testing.T) {
+		files := map[string]string{
+			"./main.go": tenLines,
+		}
+		without := e2e.NewEnvironment(t).WithFiles(files).WithArgs(".").Run()
+		with := e2e.NewEnvironment(t).WithFiles(files).WithArgs(". --line-numbers").Run()
+
+		without.AssertNoError()
+		with.AssertNoError()
+		if estimatedTokens(t, with.Output) <= estimatedTokens(t, without.Output) {
+			t.Errorf("expected more tokens with line numbers, got:\n%s\nwithout:\n%s", with.Output, without.Output)
+		}
+	})
+
+	t.Run("empty files are not numbered", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./empty.txt": "",
+			}).
+			WithArgs(". --line-numbers").
+			Run()
+
+		result.
+			AssertNoError().
+			AssertClipboardContains("START FILE: ./empty.txt\n<EMPTY FILE>\n")
+	})
+}
+
+var estimatedTokensLine = regexp.MustCompile(`Estimated tokens: (\d+)`)
+
+func estimatedTokens(t *testing.T, output string) int {
+	t.Helper()
+	match := estimatedTokensLine.FindStringSubmatch(output)
+	if match == nil {
+		t.Fatalf("no token estimate in output:\n%s", output)
+	}
+	tokens, _ := strconv.Atoi(match[1])
+	return tokens
+}
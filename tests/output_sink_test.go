@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"github.com/fargusplumdoodle/dump_dir/tests/e2e"
+	"strings"
+	"testing"
+)
+
+func TestOutputSinks(t *testing.T) {
+	t.Run("stdout receives only the file contents", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./main.go": "package main\n",
+			}).
+			WithArgs(". --stdout")
+
+		result := env.Run()
+
+		result.AssertNoError()
+		if result.Stdout != "START FILE: ./main.go\npackage main\n\nEND FILE: ./main.go\n\n" {
+			t.Errorf("Unexpected stdout: %q", result.Stdout)
+		}
+		if !strings.Contains(result.Stderr, "Total files found: 1") {
+			t.Errorf("Expected the summary on stderr, got: %q", result.Stderr)
+		}
+		if result.Clipboard != "" {
+			t.Error("Expected the clipboard not to be touched")
+		}
+	})
+
+	t.Run("diagnostics go to stderr in stdout mode", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./main.go":       "package main\n",
+				"./vendor/lib.go": "package vendor\n",
+			}).
+			WithArgs(". --skip ./vendor --stdout")
+
+		result := env.Run()
+
+		result.AssertNoError()
+		if strings.Contains(result.Stdout, "Skipping") {
+			t.Errorf("Expected no diagnostics on stdout, got: %q", result.Stdout)
+		}
+		if !strings.Contains(result.Stderr, "Skipping ignored directory: ./vendor") {
+			t.Errorf("Expected diagnostics on stderr, got: %q", result.Stderr)
+		}
+	})
+
+	t.Run("output file receives the file contents", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./src/main.go": "package main\n",
+			}).
+			WithArgs("./src --output dump.txt")
+
+		result := env.Run()
+
+		result.
+			AssertNoError().
+			AssertFileContains("dump.txt", "START FILE: ./src/main.go\npackage main\n").
+			AssertOutputContains("File contents have been written to dump.txt")
+		if result.Stdout != "" {
+			t.Errorf("Expected nothing on stdout, got: %q", result.Stdout)
+		}
+		if result.Clipboard != "" {
+			t.Error("Expected the clipboard not to be touched")
+		}
+	})
+
+	t.Run("split output file is written in numbered parts", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./src/a.txt": strings.Repeat("a", 100) + "\n",
+				"./src/b.txt": strings.Repeat("b", 100) + "\n",
+			}).
+			WithArgs("./src --output out/dump.txt --split-size 150B")
+
+		result := env.Run()
+
+		result.
+			AssertNoError().
+			AssertFileContains("out/dump.part-1.txt", "=== Part 1 of 2 ===").
+			AssertFileContains("out/dump.part-2.txt", "START FILE: ./src/b.txt")
+	})
+
+	t.Run("warnings about paths go to stderr in stdout mode", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./main.go": "package main\n",
+			}).
+			WithArgs("./main.go ./missing.go --stdout").
+			Run()
+
+		result.AssertNoError()
+		if strings.Contains(result.Stdout, "missing.go") {
+			t.Errorf("Expected no warnings on stdout, got: %q", result.Stdout)
+		}
+		if !strings.Contains(result.Stderr, "Warning: Could not process path ./missing.go") {
+			t.Errorf("Expected the warning on stderr, got: %q", result.Stderr)
+		}
+	})
+
+	t.Run("stdout mode does not carry over to the next run", func(t *testing.T) {
+		files := map[string]string{
+			"./main.go": "package main\n",
+		}
+		e2e.NewEnvironment(t).WithFiles(files).WithArgs(". --stdout").Run().AssertNoError()
+
+		result := e2e.NewEnvironment(t).WithFiles(files).WithArgs(".").Run()
+
+		result.AssertNoError()
+		if !strings.Contains(result.Stdout, "Total files found: 1") {
+			t.Errorf("Expected the summary on stdout, got stdout %q and stderr %q", result.Stdout, result.Stderr)
+		}
+	})
+}
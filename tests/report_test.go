@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"encoding/json"
+	"github.com/fargusplumdoodle/dump_dir/src"
+	"github.com/fargusplumdoodle/dump_dir/tests/e2e"
+	"strings"
+	"testing"
+)
+
+func TestJSONReport(t *testing.T) {
+	files := map[string]string{
+		"./main.go":     "package main\n\nfunc main() {}\n",
+		"./image.png":   "\x89PNG\x00\x00\x00",
+		"./big.txt":     strings.Repeat("x", 2048),
+		"./README.md":   "# Project\n",
+		".dump_dir.yml": "ignore:\n  - ./vendor\n",
+	}
+
+	t.Run("report on stdout", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs(". --max-filesize 1KB --report json").
+			Run()
+
+		result.AssertNoError()
+		var report src.Report
+		if err := json.Unmarshal([]byte(result.Stdout), &report); err != nil {
+			t.Fatalf("Expected stdout to be a JSON report: %v\n%s", err, result.Stdout)
+		}
+		if !strings.Contains(result.Stderr, "Total files found") {
+			t.Errorf("Expected the summary on stderr, got: %q", result.Stderr)
+		}
+
+		if report.Version != src.ReportVersion {
+			t.Errorf("Expected report version %d, got %d", src.ReportVersion, report.Version)
+		}
+		if report.Config.MaxFileSize != 1024 || report.Config.Format != "plain" {
+			t.Errorf("Expected the effective config, got: %+v", report.Config)
+		}
+		if len(report.Config.SkipDirs) != 1 || report.Config.SkipDirs[0] != "./vendor" {
+			t.Errorf("Expected the config file to be merged, got skip dirs: %v", report.Config.SkipDirs)
+		}
+
+		byPath := make(map[string]src.ReportFile)
+		for _, file := range report.Files {
+			byPath[file.Path] = file
+		}
+		if main := byPath["./main.go"]; main.Status != src.StatusParsed || main.Lines != 3 || main.Tokens == 0 || main.SkipReason != "" {
+			t.Errorf("Unexpected entry for main.go: %+v", main)
+		}
+		if image := byPath["./image.png"]; image.Status != src.StatusSkippedBinary || image.SkipReason != "binary file" {
+			t.Errorf("Unexpected entry for image.png: %+v", image)
+		}
+		if big := byPath["./big.txt"]; big.Status != src.StatusSkippedTooLarge || big.Size != 2048 || !strings.Contains(big.SkipReason, "max_filesize") {
+			t.Errorf("Unexpected entry for big.txt: %+v", big)
+		}
+
+		totals := report.Totals
+		if totals.Files != 5 || totals.Parsed != 3 || totals.SkippedBinary != 1 || totals.SkippedLarge != 1 {
+			t.Errorf("Unexpected totals: %+v", totals)
+		}
+		if report.Timing.StartedAt.IsZero() || report.Timing.TotalMs <= 0 {
+			t.Errorf("Expected timing to be recorded, got: %+v", report.Timing)
+		}
+	})
+
+	t.Run("internal settings are left out of the report", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./main.go":       "package main\n\nfunc main() {}\n",
+				"./docs/guide.md": "# Guide\n",
+				".dump_dir.yml":   "include:\n  - ./docs\n  - \"**/*.md\"\nignore:\n  - ./vendor\n",
+			}).
+			WithArgs("./main.go:1-2 --ask secret-question --report json").
+			Run()
+
+		result.AssertNoError()
+		var report struct {
+			Config map[string]json.RawMessage `json:"config"`
+		}
+		if err := json.Unmarshal([]byte(result.Stdout), &report); err != nil {
+			t.Fatalf("Expected stdout to be a JSON report: %v\n%s", err, result.Stdout)
+		}
+		for _, key := range []string{"priority_paths", "ignore_paths", "include_globs", "selections", "dry_run", "question"} {
+			if _, ok := report.Config[key]; ok {
+				t.Errorf("Expected %s to be left out of the report config", key)
+			}
+		}
+		if strings.Contains(result.Stdout, "secret-question") {
+			t.Errorf("Expected the question to be left out of the report, got: %s", result.Stdout)
+		}
+		if _, ok := report.Config["max_filesize"]; !ok {
+			t.Errorf("Expected the options to stay in the report config, got: %s", result.Stdout)
+		}
+	})
+
+	t.Run("report file", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs(". --stdout --report-file report.json").
+			Run()
+
+		result.AssertNoError()
+		if !strings.Contains(result.Stdout, "START FILE: ./main.go") {
+			t.Errorf("Expected the dump on stdout, got: %q", result.Stdout)
+		}
+		var report src.Report
+		if err := json.Unmarshal([]byte(result.ReadFile("report.json")), &report); err != nil {
+			t.Fatalf("Expected report.json to be a JSON report: %v", err)
+		}
+		if report.Totals.Files != 5 {
+			t.Errorf("Expected 5 files in the report, got %d", report.Totals.Files)
+		}
+	})
+
+	t.Run("report and dump cannot share stdout", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs(". --stdout --report json").
+			Run()
+
+		result.AssertError()
+	})
+}
@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fargusplumdoodle/dump_dir/tests/e2e"
+)
+
+func TestFileSelectors(t *testing.T) {
+	files := map[string]string{
+		"./src/run.go": `package src
+
+func Run() error {
+	return performDumpDir()
+}
+
+// performDumpDir does the work
+func performDumpDir() error {
+	return nil
+}
+`,
+		"./notes.txt": "one\ntwo\nthree\nfour\n",
+	}
+
+	t.Run("line range", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs("./notes.txt:2-3").
+			Run()
+
+		result.
+			AssertNoError().
+			AssertClipboardContains("START FILE: ./notes.txt (lines 2-3 of 4)\ntwo\nthree\n\nEND FILE: ./notes.txt\n")
+	})
+
+	t.Run("symbol with line numbers", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs("./src/run.go#performDumpDir --line-numbers").
+			Run()
+
+		result.
+			AssertNoError().
+			AssertClipboardContains("START FILE: ./src/run.go (performDumpDir, lines 7-10 of 10)\n" +
+				" 7 | // performDumpDir does the work\n" +
+				" 8 | func performDumpDir() error {\n" +
+				" 9 | \treturn nil\n" +
+				"10 | }\n")
+		if strings.Contains(result.Clipboard, "func Run") {
+			t.Errorf("expected only the selected function, got:\n%s", result.Clipboard)
+		}
+	})
+
+	t.Run("several selectors in one file", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs("./notes.txt:4 ./notes.txt:1 --format xml").
+			Run()
+
+		result.
+			AssertNoError().
+			AssertClipboardContains("<document path=\"./notes.txt\" region=\"line 1; line 4 of 4\">\n<source>\none\n...\nfour\n</source>\n")
+	})
+
+	t.Run("overlapping selectors show each line once", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs("./notes.txt:1-3 ./notes.txt:2-4").
+			Run()
+
+		result.
+			AssertNoError().
+			AssertClipboardContains("START FILE: ./notes.txt (lines 1-4 of 4)\none\ntwo\nthree\nfour\n\nEND FILE: ./notes.txt\n")
+	})
+
+	t.Run("selected regions count fewer tokens", func(t *testing.T) {
+		whole := e2e.NewEnvironment(t).WithFiles(files).WithArgs("./src/run.go").Run()
+		selected := e2e.NewEnvironment(t).WithFiles(files).WithArgs("./src/run.go#Run").Run()
+
+		whole.AssertNoError()
+		selected.AssertNoError()
+		if estimatedTokens(t, selected.Output) >= estimatedTokens(t, whole.Output) {
+			t.Errorf("expected fewer tokens for the selection, got:\n%s\nwhole file:\n%s", selected.Output, whole.Output)
+		}
+	})
+
+	t.Run("missing symbol fails the run", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs("./src/run.go#missing ./notes.txt").
+			Run()
+
+		result.AssertError()
+		if !strings.Contains(result.Err.Error(), "invalid selector ./src/run.go#missing: symbol not found") {
+			t.Errorf("Unexpected error: %v", result.Err)
+		}
+		if len(result.ClipboardHistory) != 0 {
+			t.Errorf("expected the clipboard to be left alone, got:\n%s", result.Clipboard)
+		}
+	})
+
+	t.Run("range past the end of the file fails the run", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs("./src/run.go:40-50 ./notes.txt").
+			Run()
+
+		result.AssertError()
+		if !strings.Contains(result.Err.Error(), "./src/run.go:40-50: starts after the end of the file (10 lines)") {
+			t.Errorf("Unexpected error: %v", result.Err)
+		}
+		if len(result.ClipboardHistory) != 0 {
+			t.Errorf("expected the clipboard to be left alone, got:\n%s", result.Clipboard)
+		}
+	})
+
+	t.Run("invalid range fails the run", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs("./src/run.go:5-2").
+			Run()
+
+		result.AssertError()
+		if len(result.ClipboardHistory) != 0 {
+			t.Errorf("expected the clipboard to be left alone, got:\n%s", result.Clipboard)
+		}
+	})
+
+	t.Run("whole file wins over a selector for it", func(t *testing.T) {
+		for _, args := range []string{"./src/run.go:2 ./src/run.go", "./src/run.go ./src/run.go:2"} {
+			result := e2e.NewEnvironment(t).
+				WithFiles(files).
+				WithArgs(args).
+				Run()
+
+			result.
+				AssertNoError().
+				AssertClipboardContains("START FILE: ./src/run.go\n" + files["./src/run.go"])
+		}
+	})
+
+	t.Run("config file include with a selector", func(t *testing.T) {
+		withConfig := map[string]string{".dump_dir.yml": "include:\n  - ./src/run.go#Run\n"}
+		for path, contents := range files {
+			withConfig[path] = contents
+		}
+		result := e2e.NewEnvironment(t).
+			WithFiles(withConfig).
+			WithArgs("./notes.txt").
+			Run()
+
+		result.
+			AssertNoError().
+			AssertClipboardContains("START FILE: ./src/run.go (Run, lines 3-5 of 10)\n")
+	})
+}
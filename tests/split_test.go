@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"github.com/fargusplumdoodle/dump_dir/tests/e2e"
+	"strings"
+	"testing"
+)
+
+func TestSplitOutput(t *testing.T) {
+	files := map[string]string{
+		"./a.txt": strings.Repeat("a", 40) + "\n",
+		"./b.txt": strings.Repeat("b", 40) + "\n",
+		"./c.txt": strings.Repeat("c", 40) + "\n",
+	}
+
+	t.Run("small output is not split", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs(". --split-size 1MB")
+
+		result := env.Run()
+
+		validator := e2e.NewOutputValidator(t, result)
+		validator.AssertSuccessfulRun()
+		if strings.Contains(result.Clipboard, "Part 1 of") {
+			t.Error("Expected no part headers when the output fits in one part")
+		}
+	})
+
+	t.Run("parts are copied one at a time when enter is pressed", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs(". --split-size 150B").
+			WithStdin("\n\n")
+
+		result := env.Run()
+
+		result.
+			AssertNoError().
+			AssertOutputContains("Output has been split into 3 parts").
+			AssertOutputContains("Part 3 of 3 has been copied to clipboard")
+		if len(result.ClipboardHistory) != 3 {
+			t.Fatalf("Expected 3 clipboard copies, got %d", len(result.ClipboardHistory))
+		}
+		if !strings.HasPrefix(result.ClipboardHistory[1], "=== Part 2 of 3 ===\n\nSTART FILE: ./b.txt") {
+			t.Errorf("Unexpected second part: %q", result.ClipboardHistory[1])
+		}
+		if strings.Contains(result.ClipboardHistory[2], "More parts follow") {
+			t.Error("Expected the last part not to announce more parts")
+		}
+	})
+
+	t.Run("copying stops when input runs out", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs(". --split-size 150B")
+
+		result := env.Run()
+
+		result.
+			AssertNoError().
+			AssertOutputContains("stopped after part 1 of 3")
+		if len(result.ClipboardHistory) != 1 {
+			t.Errorf("Expected 1 clipboard copy, got %d", len(result.ClipboardHistory))
+		}
+	})
+
+	t.Run("parts can be written to numbered files", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs(". --split-size 150B --split-files")
+
+		result := env.Run()
+
+		result.
+			AssertNoError().
+			AssertOutputContains("split into 3 parts").
+			AssertFileContains("dump.part-1.txt", "=== Part 1 of 3 ===\n\nSTART FILE: ./a.txt").
+			AssertFileContains("dump.part-3.txt", "START FILE: ./c.txt")
+	})
+
+	t.Run("parts can be limited by tokens", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs(". --split-tokens 20 --split-files")
+
+		result := env.Run()
+
+		result.
+			AssertNoError().
+			AssertOutputContains("split into 3 parts")
+	})
+
+	t.Run("a question from stdin cannot be combined with copying parts one at a time", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				".dump_dir.yml": "split_size: 150B\n",
+				"./a.txt":       files["./a.txt"],
+				"./b.txt":       files["./b.txt"],
+			}).
+			WithArgs(". --ask -").
+			WithStdin("what changed?")
+
+		result := env.Run()
+
+		result.AssertError()
+		if !strings.Contains(result.Err.Error(), "--ask - cannot be used with splitting to the clipboard") {
+			t.Errorf("Unexpected error: %v", result.Err)
+		}
+		if len(result.ClipboardHistory) != 0 {
+			t.Errorf("Expected the clipboard to be left alone, got %d copies", len(result.ClipboardHistory))
+		}
+	})
+
+	t.Run("a question from stdin works with parts written to files", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs(". --ask - --split-size 150B --split-files").
+			WithStdin("what changed?")
+
+		result := env.Run()
+
+		result.
+			AssertNoError().
+			AssertFileContains("dump.part-3.txt", "what changed?")
+	})
+}
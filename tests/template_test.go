@@ -0,0 +1,210 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fargusplumdoodle/dump_dir/tests/e2e"
+)
+
+func TestPromptTemplates(t *testing.T) {
+	files := map[string]string{
+		"./src/main.go": "package main\nfunc main() {}\n",
+		"./src/util.go": "package main\nfunc util() {}\n",
+	}
+
+	t.Run("inline template from the config file", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				".dump_dir.yml": `---
+ignore:
+  - .dump_dir.yml
+templates:
+  review: |
+    Review these {{.Stats.TotalFiles}} files:
+    {{.Tree}}
+    {{range .Files}}--- {{.Path}} ({{language .}}, {{.Lines}} lines)
+    {{.Contents}}{{end}}
+    Question: {{.Question}}
+`,
+				"./src/main.go": files["./src/main.go"],
+				"./src/util.go": files["./src/util.go"],
+			}).
+			WithArgs(". --template review --ask why?")
+
+		result := env.Run()
+
+		result.AssertNoError()
+		expected := `Review these 2 files:
+.
+└── src/
+    ├── main.go
+    └── util.go
+
+--- ./src/main.go (go, 2 lines)
+package main
+func main() {}
+--- ./src/util.go (go, 2 lines)
+package main
+func util() {}
+
+Question: why?
+`
+		if result.Clipboard != expected {
+			t.Errorf("Clipboard =\n%q\nwant:\n%q", result.Clipboard, expected)
+		}
+	})
+
+	t.Run("template from the templates directory", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./.dump_dir/templates/review.tmpl": "You are reviewing a Go project.\n\n{{.Dump}}Point out bugs only.\n",
+				"./src/main.go":                     files["./src/main.go"],
+			}).
+			WithArgs("./src --template review")
+
+		result := env.Run()
+
+		result.AssertNoError()
+		if !strings.HasPrefix(result.Clipboard, "You are reviewing a Go project.\n\nSTART FILE: ./src/main.go\n") {
+			t.Errorf("expected the instructions before the files, got:\n%s", result.Clipboard)
+		}
+		if !strings.HasSuffix(result.Clipboard, "END FILE: ./src/main.go\n\nPoint out bugs only.\n") {
+			t.Errorf("expected the instructions after the files, got:\n%s", result.Clipboard)
+		}
+	})
+
+	t.Run("template from a file path", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./prompts/explain.tmpl": "Explain {{len .Files}} file(s).\n",
+				"./src/main.go":          files["./src/main.go"],
+			}).
+			WithArgs("./src --template ./prompts/explain.tmpl")
+
+		result := env.Run()
+
+		result.AssertNoError()
+		if result.Clipboard != "Explain 1 file(s).\n" {
+			t.Errorf("unexpected clipboard: %q", result.Clipboard)
+		}
+	})
+
+	t.Run("unknown template", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs("./src --template missing")
+
+		result := env.Run()
+
+		result.AssertError()
+		if !strings.Contains(result.Err.Error(), "unknown template: missing") {
+			t.Errorf("unexpected error: %v", result.Err)
+		}
+	})
+
+	t.Run("template cannot be split into parts", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs("./src --template review --split-tokens 10")
+
+		result := env.Run()
+
+		result.AssertError()
+	})
+}
+
+func TestQuestions(t *testing.T) {
+	files := map[string]string{
+		"./pkg/main.go":       "package main\nfunc main() {}\n",
+		"./prompts/review.md": "You are a careful reviewer.\n",
+	}
+	dump := "START FILE: ./pkg/main.go\npackage main\nfunc main() {}\n\nEND FILE: ./pkg/main.go\n\n"
+
+	t.Run("question from stdin", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithStdin("find the bug\n").
+			WithArgs("./pkg --ask -")
+
+		result := env.Run()
+
+		result.AssertNoError()
+		if result.Clipboard != dump+"find the bug\n" {
+			t.Errorf("Clipboard = %q", result.Clipboard)
+		}
+	})
+
+	t.Run("prompt file and question before the files", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs("./pkg --prompt-file ./prompts/review.md --ask why? --prompt-position before")
+
+		result := env.Run()
+
+		result.AssertNoError()
+		expected := "You are a careful reviewer.\n\nwhy?\n\n" + dump
+		if result.Clipboard != expected {
+			t.Errorf("Clipboard = %q, want %q", result.Clipboard, expected)
+		}
+	})
+
+	t.Run("prompt file from the config file", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				".dump_dir.yml": `---
+ignore:
+  - .dump_dir.yml
+prompt_file: ./prompts/review.md
+`,
+				"./pkg/main.go":       files["./pkg/main.go"],
+				"./prompts/review.md": files["./prompts/review.md"],
+			}).
+			WithArgs("./pkg")
+
+		result := env.Run()
+
+		result.AssertNoError()
+		if result.Clipboard != dump+"You are a careful reviewer.\n" {
+			t.Errorf("Clipboard = %q", result.Clipboard)
+		}
+	})
+
+	t.Run("question goes in the last part of a split dump", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./pkg/a.go": "package pkg\n" + strings.Repeat("// a\n", 40),
+				"./pkg/b.go": "package pkg\n" + strings.Repeat("// b\n", 40),
+			}).
+			WithArgs("./pkg --ask why? --split-tokens 300 --split-files")
+
+		result := env.Run()
+
+		result.AssertNoError()
+		if strings.Contains(result.ReadFile("dump.part-1.txt"), "why?") {
+			t.Error("expected the question to be left out of the first part")
+		}
+		if !strings.HasSuffix(result.ReadFile("dump.part-2.txt"), "why?\n") {
+			t.Errorf("expected the question at the end of the last part, got:\n%s", result.ReadFile("dump.part-2.txt"))
+		}
+	})
+
+	t.Run("question counts toward the split limit", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./pkg/a.go": "package pkg\n" + strings.Repeat("// a\n", 10),
+			}).
+			WithArgs("./pkg --split-size 150B --split-files --ask -").
+			WithStdin(strings.Repeat("why is this here? ", 10))
+
+		result := env.Run()
+
+		result.
+			AssertNoError().
+			AssertFileContains("dump.part-1.txt", "START FILE: ./pkg/a.go").
+			AssertFileContains("dump.part-2.txt", "why is this here?")
+		if strings.Contains(result.ReadFile("dump.part-1.txt"), "why is this here?") {
+			t.Error("expected the question to get a part of its own")
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"fmt"
+	"github.com/fargusplumdoodle/dump_dir/src"
+	"github.com/fargusplumdoodle/dump_dir/tests/e2e"
+	"strings"
+	"testing"
+)
+
+func TestTokenBudget(t *testing.T) {
+	t.Run("files that do not fit are skipped", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./small.txt": "tiny\n",
+				"./big.txt":   strings.Repeat("word ", 500),
+			}).
+			WithArgs(". --max-tokens 100")
+
+		result := env.Run()
+
+		result.
+			AssertNoError().
+			AssertClipboardContains("START FILE: ./small.txt").
+			AssertOutputContains("✂️ Skipped (over budget):\n- ./big.txt")
+		if strings.Contains(result.Clipboard, "./big.txt") {
+			t.Error("Expected over budget file to be left out of the clipboard")
+		}
+	})
+
+	t.Run("config includes are kept before smaller files", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				".dump_dir.yml":         "include:\n  - ./prompts\n",
+				"./prompts/standard.md": strings.Repeat("rule ", 60),
+				"./src/a.txt":           strings.Repeat("a ", 25),
+				"./src/b.txt":           strings.Repeat("b ", 30),
+			}).
+			WithArgs("./src --max-tokens 150")
+
+		result := env.Run()
+
+		result.
+			AssertNoError().
+			AssertClipboardContains("START FILE: ./prompts/standard.md").
+			AssertClipboardContains("START FILE: ./src/a.txt").
+			AssertOutputContains("✂️ Skipped (over budget):\n- ./src/b.txt")
+	})
+
+	t.Run("the tree counts against the budget", func(t *testing.T) {
+		files := map[string]string{}
+		for i := 0; i < 6; i++ {
+			files[fmt.Sprintf("./src/module_%d.txt", i)] = strings.Repeat("word ", 20+i)
+		}
+		env := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs(". --tree --max-tokens 250")
+
+		result := env.Run()
+
+		result.
+			AssertNoError().
+			AssertClipboardContains("START FILE: ./src/module_0.txt").
+			AssertClipboardContains("over the token budget, not shown")
+		assertWithinBudget(t, result.Clipboard, 250)
+	})
+
+	t.Run("part headers count against the budget", func(t *testing.T) {
+		files := map[string]string{}
+		for i := 0; i < 6; i++ {
+			files[fmt.Sprintf("./src/module_%d.txt", i)] = strings.Repeat("word ", 20+i)
+		}
+		env := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs(". --tree --max-tokens 250 --split-tokens 100 --stdout")
+
+		result := env.Run()
+
+		result.AssertNoError()
+		if !strings.Contains(result.Stdout, "START FILE: ./src/module_0.txt") || !strings.Contains(result.Stdout, "=== Part 2 of") {
+			t.Fatalf("Expected a file in split output, got:\n%s", result.Stdout)
+		}
+		assertWithinBudget(t, result.Stdout, 250)
+	})
+
+	t.Run("the question counts against the budget", func(t *testing.T) {
+		files := map[string]string{}
+		for i := 0; i < 6; i++ {
+			files[fmt.Sprintf("./src/module_%d.txt", i)] = strings.Repeat("word ", 20+i)
+		}
+		question := strings.Repeat("please explain ", 20)
+		env := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs(". --max-tokens 200 --ask -").
+			WithStdin(question)
+
+		result := env.Run()
+
+		result.
+			AssertNoError().
+			AssertClipboardContains("START FILE: ./src/module_0.txt").
+			AssertClipboardContains(strings.TrimSpace(question))
+		assertWithinBudget(t, result.Clipboard, 200)
+	})
+
+	t.Run("the question counts in the part that carries it", func(t *testing.T) {
+		files := map[string]string{}
+		for i := 0; i < 6; i++ {
+			files[fmt.Sprintf("./src/module_%d.txt", i)] = strings.Repeat("word ", 20+i)
+		}
+		question := strings.Repeat("please explain ", 20)
+		env := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs(". --max-tokens 250 --split-tokens 100 --stdout --prompt-position before --ask -").
+			WithStdin(question)
+
+		result := env.Run()
+
+		result.AssertNoError()
+		if !strings.Contains(result.Stdout, "=== Part 1 of") || !strings.Contains(result.Stdout, strings.TrimSpace(question)) {
+			t.Fatalf("Expected the question in split output, got:\n%s", result.Stdout)
+		}
+		assertWithinBudget(t, result.Stdout, 250)
+	})
+
+	t.Run("everything is kept when the budget is large enough", func(t *testing.T) {
+		env := e2e.NewEnvironment(t).
+			WithFiles(map[string]string{
+				"./a.txt": "alpha\n",
+				"./b.txt": "beta\n",
+			}).
+			WithArgs(". --max-tokens 1000")
+
+		result := env.Run()
+
+		validator := e2e.NewOutputValidator(t, result)
+		validator.
+			AssertSuccessfulRun().
+			AssertFileInOutput("./a.txt").
+			AssertFileInOutput("./b.txt")
+		if strings.Contains(result.Output, "over budget") {
+			t.Error("Expected no files to be skipped")
+		}
+	})
+}
+
+// assertWithinBudget counts the tokens of the whole output the way the
+// default tokenizer does
+func assertWithinBudget(t *testing.T, output string, maxTokens int) {
+	t.Helper()
+	tokenizer, err := src.NewTokenizer("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokens := tokenizer.CountTokens(output); tokens > maxTokens {
+		t.Errorf("Expected the output to stay within %d tokens, got %d:\n%s", maxTokens, tokens, output)
+	}
+}
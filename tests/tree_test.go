@@ -0,0 +1,162 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fargusplumdoodle/dump_dir/tests/e2e"
+)
+
+func TestTree(t *testing.T) {
+	files := map[string]string{
+		".gitignore":        "*.log\n",
+		"./src/main.go":     "package main\nfunc main() {}\n",
+		"./src/api/api.go":  "package api\n",
+		"./src/logo.png":    "\x89PNG\x00\x00\x00",
+		"./debug.log":       "log line\n",
+		"./vendor/lib.go":   "package lib\n",
+		"./docs/readme.txt": "docs\n",
+	}
+
+	t.Run("tree before the files", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs("./src --tree").
+			Run()
+
+		result.AssertNoError()
+		expected := `START TREE
+.
+└── src/
+    ├── api/
+    │   └── api.go
+    ├── logo.png (binary, not shown)
+    └── main.go
+END TREE
+
+START FILE: ./src/api/api.go
+`
+		if !strings.HasPrefix(result.Clipboard, expected) {
+			t.Errorf("Expected the clipboard to start with\n%s\ngot:\n%s", expected, result.Clipboard)
+		}
+	})
+
+	t.Run("tree with ignored and skipped entries", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs(". --tree-ignored -e go --skip ./vendor").
+			Run()
+
+		result.AssertNoError()
+		expected := `START TREE
+.
+├── .gitignore (ignored)
+├── debug.log (ignored)
+├── docs/
+│   └── readme.txt (ignored)
+├── src/
+│   ├── api/
+│   │   └── api.go
+│   ├── logo.png (ignored)
+│   └── main.go
+└── vendor/ (skipped)
+END TREE
+`
+		if !strings.HasPrefix(result.Clipboard, expected) {
+			t.Errorf("Expected the clipboard to start with\n%s\ngot:\n%s", expected, result.Clipboard)
+		}
+	})
+
+	t.Run("tree counts toward the split limit", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs("./src --tree --split-size 160B --split-files").
+			Run()
+
+		result.
+			AssertNoError().
+			AssertOutputContains("split into 3 parts").
+			AssertFileContains("dump.part-1.txt", "START TREE").
+			AssertFileContains("dump.part-2.txt", "START FILE: ./src/api/api.go").
+			AssertFileContains("dump.part-3.txt", "START FILE: ./src/main.go")
+	})
+
+	t.Run("tree in the markdown format", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs("./src/api --tree --format markdown").
+			Run()
+
+		result.AssertNoError()
+		expected := "### Project tree\n\n```text\n.\n└── src/\n    └── api/\n        └── api.go\n```\n\n### ./src/api/api.go\n"
+		if !strings.HasPrefix(result.Clipboard, expected) {
+			t.Errorf("Expected the clipboard to start with\n%s\ngot:\n%s", expected, result.Clipboard)
+		}
+	})
+
+	t.Run("tree only", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithStdin("Which files do you need?\n").
+			WithArgs("./src --tree-only --ask -").
+			Run()
+
+		result.AssertNoError()
+		expected := `START TREE
+.
+└── src/
+    ├── api/
+    │   └── api.go (1 line, 4 tokens, 12 B)
+    ├── logo.png (binary, 7 B)
+    └── main.go (2 lines, 12 tokens, 28 B)
+END TREE
+
+Which files do you need?`
+		if !strings.HasPrefix(result.Clipboard, expected) {
+			t.Errorf("Expected the clipboard to start with\n%s\ngot:\n%s", expected, result.Clipboard)
+		}
+		if strings.Contains(result.Clipboard, "START FILE") {
+			t.Errorf("Expected no file contents, got:\n%s", result.Clipboard)
+		}
+	})
+
+	t.Run("tree only is split at line boundaries", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs("./src --tree-only --split-size 100B --split-files").
+			Run()
+
+		result.AssertNoError().AssertOutputContains("The tree has been split into")
+		part1, part2 := result.ReadFile("dump.part-1.txt"), result.ReadFile("dump.part-2.txt")
+		if !strings.HasPrefix(part1, "=== Part 1 of ") || !strings.Contains(part1, "START TREE\n") {
+			t.Errorf("Expected the first part to start the tree, got:\n%s", part1)
+		}
+		if !strings.Contains(part2, "END TREE") && !strings.Contains(result.ReadFile("dump.part-3.txt"), "END TREE") {
+			t.Errorf("Expected a later part to end the tree, got:\n%s", part2)
+		}
+	})
+
+	t.Run("tree only notes the size of a selected region", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs("./src/main.go:2 --tree-only").
+			Run()
+
+		result.AssertNoError()
+		if !strings.Contains(result.Clipboard, "main.go (line 2 of 2: 1 line, 9 tokens, 15 B)") {
+			t.Errorf("Expected the note to describe the region, got:\n%s", result.Clipboard)
+		}
+	})
+
+	t.Run("no tree without the flag", func(t *testing.T) {
+		result := e2e.NewEnvironment(t).
+			WithFiles(files).
+			WithArgs("./src").
+			Run()
+
+		result.AssertNoError()
+		if strings.Contains(result.Clipboard, "START TREE") {
+			t.Errorf("Expected no tree, got:\n%s", result.Clipboard)
+		}
+	})
+}
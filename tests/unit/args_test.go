@@ -59,6 +59,170 @@ func TestParseArgs(t *testing.T) {
 				WithDirectories("./project/src"),
 			),
 		},
+		{
+			name: "Config validate action",
+			args: []string{"config", "validate", "project/.dump_dir.yml"},
+			expectedConfig: BuildConfig(
+				WithAction("validate_config"),
+				WithConfigFiles("project/.dump_dir.yml"),
+			),
+		},
+		{
+			name:           "Unknown config command",
+			args:           []string{"config", "check"},
+			expectedConfig: nil,
+			expectedError:  ErrUnknownCommand{Value: "config check"},
+		},
+		{
+			name: "Init action",
+			args: []string{"init", "--force"},
+			expectedConfig: BuildConfig(
+				WithAction("init"),
+				WithForce(true),
+			),
+		},
+		{
+			name:           "Init with an unknown option",
+			args:           []string{"init", "--yes"},
+			expectedConfig: nil,
+			expectedError:  ErrUnknownCommand{Value: "init --yes"},
+		},
+		{
+			name: "Template and question",
+			args: []string{"--template", "review", "--ask", "is this thread safe?"},
+			expectedConfig: BuildConfig(
+				WithAction("dump_dir"),
+				WithTemplate("review"),
+				WithQuestion("is this thread safe?"),
+			),
+		},
+		{
+			name:           "Missing question",
+			args:           []string{".", "--ask"},
+			expectedConfig: nil,
+			expectedError:  ErrMissingQuestion{},
+		},
+		{
+			name: "Prompt file before the files",
+			args: []string{"--prompt-file", "prompts/review.md", "--prompt-position", "before"},
+			expectedConfig: BuildConfig(
+				WithAction("dump_dir"),
+				WithPromptFile("prompts/review.md"),
+				WithPromptPosition(PromptBefore),
+			),
+		},
+		{
+			name:           "Invalid prompt position",
+			args:           []string{".", "--prompt-position", "middle"},
+			expectedConfig: nil,
+			expectedError:  ErrInvalidPromptPosition{Value: "middle"},
+		},
+		{
+			name: "Tree",
+			args: []string{".", "--tree"},
+			expectedConfig: BuildConfig(
+				WithAction("dump_dir"),
+				WithDirectories("."),
+				WithTree(),
+				WithFlagsGiven("tree"),
+			),
+		},
+		{
+			name: "Tree with ignored entries",
+			args: []string{".", "--tree-ignored"},
+			expectedConfig: BuildConfig(
+				WithAction("dump_dir"),
+				WithDirectories("."),
+				WithTreeIgnored(),
+				WithFlagsGiven("tree", "tree-ignored"),
+			),
+		},
+		{
+			name: "Tree only",
+			args: []string{".", "--tree-only"},
+			expectedConfig: BuildConfig(
+				WithAction("dump_dir"),
+				WithDirectories("."),
+				WithTreeOnly(),
+				WithFlagsGiven("tree", "tree-only"),
+			),
+		},
+		{
+			name:           "Tree only with a template",
+			args:           []string{".", "--tree-only", "--template", "review"},
+			expectedConfig: nil,
+			expectedError:  ErrConflictingFlags{First: "--tree-only", Second: "--template"},
+		},
+		{
+			name:           "Tree only with a token budget",
+			args:           []string{".", "--tree-only", "--max-tokens", "1000"},
+			expectedConfig: nil,
+			expectedError:  ErrConflictingFlags{First: "--tree-only", Second: "--max-tokens (use --split-tokens)"},
+		},
+		{
+			name: "Line numbers",
+			args: []string{".", "--line-numbers"},
+			expectedConfig: BuildConfig(
+				WithAction("dump_dir"),
+				WithDirectories("."),
+				WithLineNumbers(),
+				WithFlagsGiven("line-numbers"),
+			),
+		},
+		{
+			name: "Line range and symbol selectors",
+			args: []string{"project/src/main.go:40-120", "project/src/main.go#main", "project/src/util.go:7"},
+			expectedConfig: BuildConfig(
+				WithAction("dump_dir"),
+				WithSpecificFiles("./project/src/main.go", "./project/src/util.go"),
+				WithSelection("./project/src/main.go", FileSelector{Start: 40, End: 120}, FileSelector{Symbol: "main"}),
+				WithSelection("./project/src/util.go", FileSelector{Start: 7, End: 7}),
+			),
+		},
+		{
+			name: "Whole file wins over a selector for it",
+			args: []string{"project/src/main.go:40-120", "project/src/main.go", "project/src/util.go", "project/src/util.go#util"},
+			expectedConfig: BuildConfig(
+				WithAction("dump_dir"),
+				WithSpecificFiles("./project/src/main.go", "./project/src/util.go"),
+			),
+		},
+		{
+			name:           "Backwards line range",
+			args:           []string{"project/src/main.go:120-40"},
+			expectedConfig: nil,
+			expectedError:  ErrInvalidSelector{Value: "project/src/main.go:120-40", Reason: "lines are counted from 1 and the range must not run backwards"},
+		},
+		{
+			name:           "Question and prompt file both from stdin",
+			args:           []string{".", "--ask", "-", "--prompt-file", "-"},
+			expectedConfig: nil,
+			expectedError:  ErrConflictingFlags{First: "--ask -", Second: "--prompt-file -"},
+		},
+		{
+			name:           "Question from stdin with parts copied one at a time",
+			args:           []string{".", "--ask", "-", "--split-tokens", "1000"},
+			expectedConfig: nil,
+			expectedError:  ErrConflictingFlags{First: "--ask -", Second: "splitting to the clipboard (use --split-files, --stdout or --output)"},
+		},
+		{
+			name:           "Prompt file from stdin with parts copied one at a time",
+			args:           []string{".", "--prompt-file", "-", "--split-size", "10KB"},
+			expectedConfig: nil,
+			expectedError:  ErrConflictingFlags{First: "--prompt-file -", Second: "splitting to the clipboard (use --split-files, --stdout or --output)"},
+		},
+		{
+			name: "Question from stdin with parts written to files",
+			args: []string{".", "--ask", "-", "--split-tokens", "1000", "--split-files"},
+			expectedConfig: BuildConfig(
+				WithAction("dump_dir"),
+				WithDirectories("."),
+				WithQuestion("-"),
+				WithSplitTokens(1000),
+				WithSplitToFiles(true),
+				WithFlagsGiven("split-tokens", "split-files"),
+			),
+		},
 		{
 			name: "Help action",
 			args: []string{"--help"},
@@ -98,6 +262,7 @@ func TestParseArgs(t *testing.T) {
 				WithAction("dump_dir"),
 				WithDirectories("./project/src"),
 				WithIncludeIgnored(true),
+				WithFlagsGiven("include-ignored"),
 			),
 		},
 		{
@@ -117,6 +282,7 @@ func TestParseArgs(t *testing.T) {
 				WithSkipDirs("./project/src/vendor"),
 				WithIncludeIgnored(true),
 				WithSpecificFiles("./project/config.json"),
+				WithFlagsGiven("include-ignored"),
 			),
 		},
 		{
@@ -126,6 +292,7 @@ func TestParseArgs(t *testing.T) {
 				WithAction("dump_dir"),
 				WithDirectories("./project/src"),
 				WithMaxFileSize(1000),
+				WithFlagsGiven("max-filesize"),
 			),
 		},
 		{
@@ -135,6 +302,7 @@ func TestParseArgs(t *testing.T) {
 				WithAction("dump_dir"),
 				WithDirectories("./project/src"),
 				WithMaxFileSize(500*1024),
+				WithFlagsGiven("max-filesize"),
 			),
 		},
 		{
@@ -144,6 +312,7 @@ func TestParseArgs(t *testing.T) {
 				WithAction("dump_dir"),
 				WithDirectories("./project/src"),
 				WithMaxFileSize(2*1024*1024),
+				WithFlagsGiven("max-filesize"),
 			),
 		},
 		{
@@ -186,6 +355,250 @@ func TestParseArgs(t *testing.T) {
 				WithSkipDirs("./node_modules"),
 			),
 		},
+		{
+			name: "Output format",
+			args: []string{".", "--format", "xml"},
+			expectedConfig: BuildConfig(
+				WithAction("dump_dir"),
+				WithDirectories("."),
+				WithFormat("xml"),
+			),
+		},
+		{
+			name: "Output format short flag",
+			args: []string{".", "-f", "markdown"},
+			expectedConfig: BuildConfig(
+				WithAction("dump_dir"),
+				WithDirectories("."),
+				WithFormat("markdown"),
+			),
+		},
+		{
+			name:           "Unknown output format",
+			args:           []string{".", "--format", "yaml"},
+			expectedConfig: nil,
+			expectedError:  ErrInvalidFormat{Value: "yaml"},
+		},
+		{
+			name:           "Missing output format",
+			args:           []string{".", "--format"},
+			expectedConfig: nil,
+			expectedError:  ErrInvalidFormat{Value: ""},
+		},
+		{
+			name: "Profile",
+			args: []string{"--profile", "backend"},
+			expectedConfig: BuildConfig(
+				WithAction("dump_dir"),
+				WithProfile("backend"),
+			),
+		},
+		{
+			name:           "Profile without config",
+			args:           []string{".", "-p", "backend", "-nc"},
+			expectedConfig: nil,
+			expectedError:  ErrConflictingFlags{First: "--profile", Second: "--no-config"},
+		},
+		{
+			name: "ls lists the working directory",
+			args: []string{"ls"},
+			expectedConfig: BuildConfig(
+				WithAction("dump_dir"),
+				WithDirectories("."),
+				WithDryRun(true),
+			),
+		},
+		{
+			name: "Dry run with filters",
+			args: []string{"--dry-run", "-e", "go"},
+			expectedConfig: BuildConfig(
+				WithAction("dump_dir"),
+				WithDirectories("."),
+				WithExtensions("go"),
+				WithDryRun(true),
+			),
+		},
+		{
+			name: "JSON report file",
+			args: []string{".", "--report-file", "report.json"},
+			expectedConfig: BuildConfig(
+				WithAction("dump_dir"),
+				WithDirectories("."),
+				WithReport("json", "report.json"),
+			),
+		},
+		{
+			name:           "Unknown report format",
+			args:           []string{".", "--report", "xml"},
+			expectedConfig: nil,
+			expectedError:  ErrInvalidReport{Value: "xml"},
+		},
+		{
+			name: "Top files",
+			args: []string{".", "--top", "5"},
+			expectedConfig: BuildConfig(
+				WithAction("dump_dir"),
+				WithDirectories("."),
+				WithTop(5),
+				WithFlagsGiven("top"),
+			),
+		},
+		{
+			name:           "Invalid top count",
+			args:           []string{".", "--top", "0"},
+			expectedConfig: nil,
+			expectedError:  ErrInvalidTop{Value: "0"},
+		},
+		{
+			name: "Tokenizer",
+			args: []string{".", "--tokenizer", "cl100k_base"},
+			expectedConfig: BuildConfig(
+				WithAction("dump_dir"),
+				WithDirectories("."),
+				WithTokenizer("cl100k_base"),
+			),
+		},
+		{
+			name:           "Unknown tokenizer",
+			args:           []string{".", "--tokenizer", "gpt2"},
+			expectedConfig: nil,
+			expectedError:  ErrInvalidTokenizer{Value: "gpt2"},
+		},
+		{
+			name: "Max tokens",
+			args: []string{".", "--max-tokens", "100000"},
+			expectedConfig: BuildConfig(
+				WithAction("dump_dir"),
+				WithDirectories("."),
+				WithMaxTokens(100000),
+				WithFlagsGiven("max-tokens"),
+			),
+		},
+		{
+			name:           "Invalid max tokens",
+			args:           []string{".", "-t", "lots"},
+			expectedConfig: nil,
+			expectedError:  ErrInvalidMaxTokens{Value: "lots"},
+		},
+		{
+			name: "Split into parts by tokens and write files",
+			args: []string{".", "--split-tokens", "30000", "--split-files"},
+			expectedConfig: BuildConfig(
+				WithAction("dump_dir"),
+				WithDirectories("."),
+				WithSplitTokens(30000),
+				WithSplitToFiles(true),
+				WithFlagsGiven("split-tokens", "split-files"),
+			),
+		},
+		{
+			name: "Split into parts by size",
+			args: []string{".", "--split-size", "100KB"},
+			expectedConfig: BuildConfig(
+				WithAction("dump_dir"),
+				WithDirectories("."),
+				WithSplitSize(100*1024),
+				WithFlagsGiven("split-size"),
+			),
+		},
+		{
+			name:           "Invalid split size",
+			args:           []string{".", "--split-size", "big"},
+			expectedConfig: nil,
+			expectedError:  ErrInvalidSplitSize{Value: "big"},
+		},
+		{
+			name: "Write to stdout",
+			args: []string{".", "--stdout"},
+			expectedConfig: BuildConfig(
+				WithAction("dump_dir"),
+				WithDirectories("."),
+				WithStdout(true),
+				WithFlagsGiven("stdout"),
+			),
+		},
+		{
+			name: "Switches turned off",
+			args: []string{".", "--no-stdout", "--no-line-numbers", "--no-include-ignored"},
+			expectedConfig: BuildConfig(
+				WithAction("dump_dir"),
+				WithDirectories("."),
+				WithFlagsGiven("stdout", "line-numbers", "include-ignored"),
+			),
+		},
+		{
+			name: "Turning off the tree turns off its variants",
+			args: []string{".", "--tree-only", "--no-tree"},
+			expectedConfig: BuildConfig(
+				WithAction("dump_dir"),
+				WithDirectories("."),
+				WithFlagsGiven("tree", "tree-ignored", "tree-only"),
+			),
+		},
+		{
+			name: "Max filesize equal to the default",
+			args: []string{".", "--max-filesize", "500KB"},
+			expectedConfig: BuildConfig(
+				WithAction("dump_dir"),
+				WithDirectories("."),
+				WithFlagsGiven("max-filesize"),
+			),
+		},
+		{
+			name: "Write to output file",
+			args: []string{".", "-o", "dump.txt"},
+			expectedConfig: BuildConfig(
+				WithAction("dump_dir"),
+				WithDirectories("."),
+				WithOutputFile("dump.txt"),
+			),
+		},
+		{
+			name:           "Missing output file",
+			args:           []string{".", "--output"},
+			expectedConfig: nil,
+			expectedError:  ErrMissingOutputFile{},
+		},
+		{
+			name: "Git selection",
+			args: []string{"--git-changed", "--git-staged", "--git-since", "main"},
+			expectedConfig: BuildConfig(
+				WithAction("dump_dir"),
+				WithGitChanged(true),
+				WithGitStaged(true),
+				WithGitSince("main"),
+				WithFlagsGiven("git-changed", "git-staged"),
+			),
+		},
+		{
+			name:           "Missing git ref",
+			args:           []string{"--git-since"},
+			expectedConfig: nil,
+			expectedError:  ErrMissingGitRef{},
+		},
+		{
+			name: "Diff only",
+			args: []string{"--diff", "--diff-base", "main"},
+			expectedConfig: BuildConfig(
+				WithAction("dump_dir"),
+				WithDiff(DiffModeOnly),
+				WithDiffBase("main"),
+			),
+		},
+		{
+			name: "Diff with full contents",
+			args: []string{"--diff-full"},
+			expectedConfig: BuildConfig(
+				WithAction("dump_dir"),
+				WithDiff(DiffModeFull),
+			),
+		},
+		{
+			name:           "Missing diff base",
+			args:           []string{"--diff", "--diff-base"},
+			expectedConfig: nil,
+			expectedError:  ErrMissingGitRef{},
+		},
 		{
 			name: "Using --glob instead of -g",
 			args: []string{".", "--glob", "*.go"},
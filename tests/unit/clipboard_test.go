@@ -0,0 +1,152 @@
+package unit
+
+import (
+	"bytes"
+	. "github.com/fargusplumdoodle/dump_dir/src"
+	"strings"
+	"testing"
+)
+
+func fakeEnv(vars map[string]string) func(string) string {
+	return func(key string) string {
+		return vars[key]
+	}
+}
+
+func TestOSC52Clipboard(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		text     string
+		expected string
+	}{
+		{
+			name:     "plain_terminal",
+			text:     "hello",
+			expected: "\x1b]52;c;aGVsbG8=\x07",
+		},
+		{
+			name:     "inside_tmux",
+			env:      map[string]string{"TMUX": "/tmp/tmux-1000/default,1,0"},
+			text:     "hello",
+			expected: "\x1bPtmux;\x1b\x1b]52;c;aGVsbG8=\x07\x1b\\",
+		},
+		{
+			name:     "inside_screen",
+			env:      map[string]string{"STY": "1234.pts-0.host"},
+			text:     "hello",
+			expected: "\x1bP\x1b]52;c;aGVsbG8=\x07\x1b\\",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			clipboard := NewOSC52Clipboard(fakeEnv(tt.env))
+			clipboard.Out = &out
+
+			if err := clipboard.WriteAll(tt.text); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if out.String() != tt.expected {
+				t.Errorf("WriteAll(%q) wrote %q, want %q", tt.text, out.String(), tt.expected)
+			}
+		})
+	}
+
+	t.Run("screen_splits_large_payloads_into_chunks", func(t *testing.T) {
+		clipboard := NewOSC52Clipboard(fakeEnv(map[string]string{"TERM": "screen-256color"}))
+
+		sequence := clipboard.Sequence(string(make([]byte, 300)))
+
+		chunks := bytes.Count([]byte(sequence), []byte("\x1bP"))
+		if chunks < 2 {
+			t.Errorf("Expected the payload to be split into several chunks, got %d", chunks)
+		}
+	})
+
+	t.Run("tmux_splits_large_payloads_into_chunks", func(t *testing.T) {
+		clipboard := NewOSC52Clipboard(fakeEnv(map[string]string{"TMUX": "/tmp/tmux-1000/default,1,0"}))
+
+		sequence := clipboard.Sequence(strings.Repeat("a", 10000))
+
+		chunks := strings.Split(sequence, "\x1bPtmux;")[1:]
+		if len(chunks) < 2 {
+			t.Fatalf("Expected the payload to be split into several chunks, got %d", len(chunks))
+		}
+		var unwrapped strings.Builder
+		for _, chunk := range chunks {
+			unwrapped.WriteString(strings.ReplaceAll(strings.TrimSuffix(chunk, "\x1b\\"), "\x1b\x1b", "\x1b"))
+		}
+		if unwrapped.String() != NewOSC52Clipboard(fakeEnv(nil)).Sequence(strings.Repeat("a", 10000)) {
+			t.Errorf("Unwrapped tmux chunks do not rebuild the OSC 52 sequence")
+		}
+	})
+
+	t.Run("reports_payloads_too_large_for_terminals", func(t *testing.T) {
+		clipboard := NewOSC52Clipboard(fakeEnv(nil))
+
+		if clipboard.ExceedsTerminalLimit(strings.Repeat("a", 1000)) {
+			t.Errorf("Expected a small payload to be within the limit")
+		}
+		if !clipboard.ExceedsTerminalLimit(strings.Repeat("a", 200_000)) {
+			t.Errorf("Expected a 200 KB payload to exceed the limit")
+		}
+	})
+}
+
+func TestCopyToClipboardWarnsAboutLargeOSC52Payloads(t *testing.T) {
+	clipboard := NewOSC52Clipboard(fakeEnv(nil))
+	clipboard.Out = &bytes.Buffer{}
+	var console bytes.Buffer
+
+	if !CopyToClipboard(clipboard, strings.Repeat("a", 200_000), &console) {
+		t.Fatalf("Expected the copy to succeed")
+	}
+	if !strings.Contains(console.String(), "larger than many terminals accept") {
+		t.Errorf("Expected a size warning, got %q", console.String())
+	}
+}
+
+func TestNewClipboardManager(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		expectOSC52 bool
+	}{
+		{name: "local_session_uses_system_clipboard", env: map[string]string{}, expectOSC52: false},
+		{name: "ssh_session_uses_osc52", env: map[string]string{"SSH_TTY": "/dev/pts/0"}, expectOSC52: true},
+		{name: "override_forces_osc52", env: map[string]string{"DUMP_DIR_CLIPBOARD": "osc52"}, expectOSC52: true},
+		{name: "override_forces_system", env: map[string]string{"SSH_TTY": "/dev/pts/0", "DUMP_DIR_CLIPBOARD": "system"}, expectOSC52: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, isOSC52 := NewClipboardManager(fakeEnv(tt.env)).(*OSC52Clipboard)
+			if isOSC52 != tt.expectOSC52 {
+				t.Errorf("Expected OSC 52 clipboard: %v, got: %v", tt.expectOSC52, isOSC52)
+			}
+		})
+	}
+}
+
+// limitedClipboard is a clipboard with a terminal limit that is not
+// the OSC 52 one
+type limitedClipboard struct{ limit int }
+
+func (c *limitedClipboard) WriteAll(string) error { return nil }
+
+func (c *limitedClipboard) ExceedsTerminalLimit(text string) bool { return len(text) > c.limit }
+
+func TestCopyToClipboardWarnsForAnyTerminalLimitedClipboard(t *testing.T) {
+	var console bytes.Buffer
+	CopyToClipboard(&limitedClipboard{limit: 10}, "short", &console)
+	if console.Len() != 0 {
+		t.Errorf("Expected no warning within the limit, got %q", console.String())
+	}
+
+	CopyToClipboard(&limitedClipboard{limit: 10}, strings.Repeat("a", 20), &console)
+	if !strings.Contains(console.String(), "larger than many terminals accept") {
+		t.Errorf("Expected a size warning, got %q", console.String())
+	}
+}
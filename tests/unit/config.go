@@ -77,3 +77,205 @@ func WithGlobPatterns(patterns ...string) ConfigOption {
 		c.GlobPatterns = patterns
 	}
 }
+
+func WithFormat(format string) ConfigOption {
+	return func(c *Config) {
+		c.Format = format
+	}
+}
+
+func WithConfigFiles(paths ...string) ConfigOption {
+	return func(c *Config) {
+		c.ConfigFiles = paths
+	}
+}
+
+func WithForce(force bool) ConfigOption {
+	return func(c *Config) {
+		c.Force = force
+	}
+}
+
+func WithTemplate(template string) ConfigOption {
+	return func(c *Config) {
+		c.Template = template
+	}
+}
+
+func WithQuestion(question string) ConfigOption {
+	return func(c *Config) {
+		c.Question = question
+	}
+}
+
+func WithPromptFile(path string) ConfigOption {
+	return func(c *Config) {
+		c.PromptFile = path
+	}
+}
+
+func WithPromptPosition(position PromptPosition) ConfigOption {
+	return func(c *Config) {
+		c.PromptPosition = position
+	}
+}
+
+func WithTree() ConfigOption {
+	return func(c *Config) {
+		c.Tree = true
+	}
+}
+
+func WithTreeIgnored() ConfigOption {
+	return func(c *Config) {
+		c.Tree = true
+		c.TreeIgnored = true
+	}
+}
+
+func WithTreeOnly() ConfigOption {
+	return func(c *Config) {
+		c.Tree = true
+		c.TreeOnly = true
+	}
+}
+
+func WithLineNumbers() ConfigOption {
+	return func(c *Config) {
+		c.LineNumbers = true
+	}
+}
+
+// WithSelection adds selectors for a file to the Selections field of the Config
+func WithSelection(path string, selectors ...FileSelector) ConfigOption {
+	return func(c *Config) {
+		if c.Selections == nil {
+			c.Selections = make(map[string][]FileSelector)
+		}
+		c.Selections[path] = append(c.Selections[path], selectors...)
+	}
+}
+
+func WithProfile(profile string) ConfigOption {
+	return func(c *Config) {
+		c.Profile = profile
+	}
+}
+
+func WithDryRun(dryRun bool) ConfigOption {
+	return func(c *Config) {
+		c.DryRun = dryRun
+	}
+}
+
+func WithReport(format, file string) ConfigOption {
+	return func(c *Config) {
+		c.Report = format
+		c.ReportFile = file
+	}
+}
+
+func WithTop(top int) ConfigOption {
+	return func(c *Config) {
+		c.Top = top
+	}
+}
+
+func WithTokenizer(tokenizer string) ConfigOption {
+	return func(c *Config) {
+		c.Tokenizer = tokenizer
+	}
+}
+
+func WithMaxTokens(maxTokens int) ConfigOption {
+	return func(c *Config) {
+		c.MaxTokens = maxTokens
+	}
+}
+
+func WithPriorityPaths(paths ...string) ConfigOption {
+	return func(c *Config) {
+		c.PriorityPaths = paths
+	}
+}
+
+func WithSplitTokens(tokens int) ConfigOption {
+	return func(c *Config) {
+		c.SplitTokens = tokens
+	}
+}
+
+func WithSplitSize(size int64) ConfigOption {
+	return func(c *Config) {
+		c.SplitSize = size
+	}
+}
+
+func WithSplitToFiles(splitToFiles bool) ConfigOption {
+	return func(c *Config) {
+		c.SplitToFiles = splitToFiles
+	}
+}
+
+func WithOutputFile(outputFile string) ConfigOption {
+	return func(c *Config) {
+		c.OutputFile = outputFile
+	}
+}
+
+func WithStdout(stdout bool) ConfigOption {
+	return func(c *Config) {
+		c.Stdout = stdout
+	}
+}
+
+func WithGitChanged(gitChanged bool) ConfigOption {
+	return func(c *Config) {
+		c.GitChanged = gitChanged
+	}
+}
+
+func WithGitStaged(gitStaged bool) ConfigOption {
+	return func(c *Config) {
+		c.GitStaged = gitStaged
+	}
+}
+
+func WithGitSince(ref string) ConfigOption {
+	return func(c *Config) {
+		c.GitSince = ref
+	}
+}
+
+func WithIgnorePaths(paths ...string) ConfigOption {
+	return func(c *Config) {
+		c.IgnorePaths = paths
+	}
+}
+
+func WithIncludeGlobs(patterns ...string) ConfigOption {
+	return func(c *Config) {
+		c.IncludeGlobs = patterns
+	}
+}
+
+func WithDiff(mode DiffMode) ConfigOption {
+	return func(c *Config) {
+		c.Diff = mode
+	}
+}
+
+func WithDiffBase(ref string) ConfigOption {
+	return func(c *Config) {
+		c.DiffBase = ref
+	}
+}
+
+func WithFlagsGiven(names ...string) ConfigOption {
+	return func(c *Config) {
+		c.FlagsGiven = make(map[string]bool, len(names))
+		for _, name := range names {
+			c.FlagsGiven[name] = true
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package unit
 
 import (
+	"errors"
 	"github.com/google/go-cmp/cmp"
 	"github.com/spf13/afero"
 	"os"
@@ -11,11 +12,16 @@ import (
 
 func TestConfigLoader(t *testing.T) {
 	tests := []struct {
-		name           string
-		configContent  string
-		baseConfig     Config
-		expectedConfig Config
-		expectError    bool
+		name              string
+		configContent     string
+		userConfigContent string
+		// parentConfigContent is written to the parent directory, which
+		// is the repository root when parentIsRepo is set
+		parentConfigContent string
+		parentIsRepo        bool
+		baseConfig          Config
+		expectedConfig      Config
+		expectError         bool
 	}{
 		{
 			name: "No config file present",
@@ -48,6 +54,7 @@ ignore:
 			expectedConfig: *BuildConfig(
 				WithDirectories("./src"),
 				WithSkipDirs("./src/subdir"),
+				WithIgnorePaths("./src/subdir"),
 			),
 		},
 		{
@@ -61,6 +68,27 @@ include:
 			expectedConfig: *BuildConfig(
 				WithSpecificFiles("./src/main.go"),
 				WithDirectories("./src/subdir"),
+				WithPriorityPaths("./src/main.go", "./src/subdir"),
+			),
+		},
+		{
+			name: "Config with glob patterns",
+			configContent: `
+include:
+  - docs/**/*.md
+ignore:
+  - "**/*.snap"
+  - ./src/subdir
+`,
+			baseConfig: *BuildConfig(
+				WithDirectories("./src"),
+			),
+			expectedConfig: *BuildConfig(
+				WithDirectories("./src"),
+				WithSkipDirs("./src/subdir"),
+				WithGlobPatterns("!**/*.snap"),
+				WithIgnorePaths("**/*.snap", "./src/subdir"),
+				WithIncludeGlobs("docs/**/*.md"),
 			),
 		},
 		{
@@ -77,8 +105,352 @@ ignore:
 				WithSpecificFiles("./src/main.go"),
 				WithDirectories("./src"),
 				WithSkipDirs("./src/subdir"),
+				WithIgnorePaths("./src/subdir"),
+				WithPriorityPaths("./src/main.go", "./src"),
+			),
+		},
+		{
+			name: "Default profile applies when none is selected",
+			configContent: `
+profiles:
+  default:
+    paths:
+      - ./src
+    extensions: [go]
+    max_filesize: 1MB
+    format: xml
+    max_tokens: 1000
+  docs:
+    extensions: [md]
+`,
+			baseConfig: *BuildConfig(),
+			expectedConfig: *BuildConfig(
+				WithDirectories("./src"),
+				WithExtensions("go"),
+				WithMaxFileSize(1024*1024),
+				WithFormat("xml"),
+				WithMaxTokens(1000),
+				WithProfile("default"),
+			),
+		},
+		{
+			name: "Selected profile with command line overrides",
+			configContent: `
+ignore:
+  - ./dist
+profiles:
+  default:
+    extensions: [go]
+  docs:
+    include:
+      - ./src/main.go
+    ignore:
+      - ./src/subdir
+    extensions: [txt]
+    globs: ["*.md"]
+    max_tokens: 5000
+`,
+			baseConfig: *BuildConfig(
+				WithDirectories("./src"),
+				WithExtensions("md"),
+				WithMaxTokens(200),
+				WithProfile("docs"),
+			),
+			expectedConfig: *BuildConfig(
+				WithDirectories("./src"),
+				WithExtensions("md"),
+				WithGlobPatterns("*.md"),
+				WithMaxTokens(200),
+				WithProfile("docs"),
+				WithSkipDirs("./dist", "./src/subdir"),
+				WithIgnorePaths("./dist", "./src/subdir"),
+				WithSpecificFiles("./src/main.go"),
+				WithPriorityPaths("./src/main.go"),
+			),
+		},
+		{
+			name: "Unknown profile",
+			configContent: `
+profiles:
+  backend:
+    extensions: [go]
+`,
+			baseConfig:  *BuildConfig(WithProfile("frontend")),
+			expectError: true,
+		},
+		{
+			name: "Invalid profile max filesize",
+			configContent: `
+profiles:
+  default:
+    max_filesize: huge
+`,
+			baseConfig:  *BuildConfig(),
+			expectError: true,
+		},
+		{
+			name: "Config file sets every option",
+			configContent: `
+paths: [./src]
+extensions: [go, md]
+globs: ["!**/*_test.go"]
+include_ignored: true
+max_filesize: 1048576
+format: markdown
+tokenizer: cl100k_base
+max_tokens: 8000
+top: 5
+split_tokens: 4000
+split_size: 64KB
+split_files: true
+output: dump.txt
+stdout: true
+git_changed: true
+git_staged: true
+git_since: main
+diff: full
+diff_base: develop
+report: json
+report_file: report.json
+`,
+			baseConfig: *BuildConfig(),
+			expectedConfig: *BuildConfig(
+				WithDirectories("./src"),
+				WithExtensions("go", "md"),
+				WithGlobPatterns("!**/*_test.go"),
+				WithIncludeIgnored(true),
+				WithMaxFileSize(1024*1024),
+				WithFormat("markdown"),
+				WithTokenizer("cl100k_base"),
+				WithMaxTokens(8000),
+				WithTop(5),
+				WithSplitTokens(4000),
+				WithSplitSize(64*1024),
+				WithSplitToFiles(true),
+				WithOutputFile("dump.txt"),
+				WithStdout(true),
+				WithGitChanged(true),
+				WithGitStaged(true),
+				WithGitSince("main"),
+				WithDiff(DiffModeFull),
+				WithDiffBase("develop"),
+				WithReport("json", "report.json"),
+			),
+		},
+		{
+			name: "Config file sets the tree and line numbers",
+			configContent: `
+tree_only: true
+line_numbers: true
+`,
+			baseConfig: *BuildConfig(),
+			expectedConfig: *BuildConfig(
+				WithTreeOnly(),
+				WithLineNumbers(),
+			),
+		},
+		{
+			name: "Command line beats the config file",
+			configContent: `
+paths: [./src/subdir]
+extensions: [md]
+max_filesize: 1MB
+format: markdown
+max_tokens: 8000
+git_since: main
+`,
+			baseConfig: *BuildConfig(
+				WithDirectories("./src"),
+				WithExtensions("go"),
+				WithMaxFileSize(1024),
+				WithFormat("xml"),
+				WithMaxTokens(100),
+				WithGitSince("develop"),
+			),
+			expectedConfig: *BuildConfig(
+				WithDirectories("./src"),
+				WithExtensions("go"),
+				WithMaxFileSize(1024),
+				WithFormat("xml"),
+				WithMaxTokens(100),
+				WithGitSince("develop"),
+			),
+		},
+		{
+			name: "Command line switches and defaults beat the config file",
+			configContent: `
+stdout: true
+line_numbers: true
+tree_only: true
+max_filesize: 1MB
+split_tokens: 5000
+`,
+			baseConfig: *BuildConfig(
+				WithDirectories("./src"),
+				WithMaxFileSize(DefaultMaxFileSize),
+				WithFlagsGiven("stdout", "line-numbers", "tree", "tree-ignored", "tree-only", "max-filesize"),
+			),
+			expectedConfig: *BuildConfig(
+				WithDirectories("./src"),
+				WithMaxFileSize(DefaultMaxFileSize),
+				WithSplitTokens(5000),
+				WithFlagsGiven("stdout", "line-numbers", "tree", "tree-ignored", "tree-only", "max-filesize"),
+			),
+		},
+		{
+			name: "User config file applies without a project file",
+			userConfigContent: `
+format: xml
+ignore:
+  - "**/subdir"
+`,
+			baseConfig: *BuildConfig(WithDirectories("./src")),
+			expectedConfig: *BuildConfig(
+				WithDirectories("./src"),
+				WithFormat("xml"),
+				WithGlobPatterns("!**/subdir"),
+				WithIgnorePaths("**/subdir"),
+			),
+		},
+		{
+			name: "Project file beats the user file",
+			userConfigContent: `
+format: xml
+max_tokens: 1000
+include_ignored: true
+extensions: [md]
+ignore:
+  - ./dist
+`,
+			configContent: `
+format: markdown
+include_ignored: false
+ignore:
+  - ./src/subdir
+`,
+			baseConfig: *BuildConfig(WithDirectories("./src")),
+			expectedConfig: *BuildConfig(
+				WithDirectories("./src"),
+				WithFormat("markdown"),
+				WithMaxTokens(1000),
+				WithExtensions("md"),
+				WithSkipDirs("/home/user/.config/dump_dir/dist", "./src/subdir"),
+				WithIgnorePaths("/home/user/.config/dump_dir/dist", "./src/subdir"),
+			),
+		},
+		{
+			name: "Profile beats the top level options",
+			configContent: `
+profile: docs
+format: xml
+extensions: [go]
+profiles:
+  docs:
+    extensions: [md]
+`,
+			baseConfig: *BuildConfig(WithDirectories("./src")),
+			expectedConfig: *BuildConfig(
+				WithDirectories("./src"),
+				WithFormat("xml"),
+				WithExtensions("md"),
+				WithProfile("docs"),
+			),
+		},
+		{
+			name: "Project top level options beat the user file's profile",
+			userConfigContent: `
+profiles:
+  default:
+    format: xml
+    extensions: [md]
+`,
+			configContent: `
+format: markdown
+`,
+			baseConfig: *BuildConfig(WithDirectories("./src")),
+			expectedConfig: *BuildConfig(
+				WithDirectories("./src"),
+				WithFormat("markdown"),
+				WithExtensions("md"),
+				WithProfile("default"),
+			),
+		},
+		{
+			name: "Project profile beats the user file's profile",
+			userConfigContent: `
+profiles:
+  docs:
+    format: xml
+    max_tokens: 1000
+`,
+			configContent: `
+format: plain
+profiles:
+  docs:
+    format: markdown
+`,
+			baseConfig: *BuildConfig(WithDirectories("./src"), WithProfile("docs")),
+			expectedConfig: *BuildConfig(
+				WithDirectories("./src"),
+				WithFormat("markdown"),
+				WithMaxTokens(1000),
+				WithProfile("docs"),
+			),
+		},
+		{
+			name:          "Invalid diff mode",
+			configContent: `diff: everything`,
+			baseConfig:    *BuildConfig(),
+			expectError:   true,
+		},
+		{
+			name:          "Invalid tokenizer",
+			configContent: `tokenizer: words`,
+			baseConfig:    *BuildConfig(),
+			expectError:   true,
+		},
+		{
+			name: "Stdout and a report on stdout from the config file",
+			configContent: `
+stdout: true
+report: json
+`,
+			baseConfig:  *BuildConfig(),
+			expectError: true,
+		},
+		{
+			name: "Config files up to the repository root",
+			parentConfigContent: `
+include:
+  - ./unit/src/main.go
+  - ./README.md
+ignore:
+  - ./unit/src/subdir
+  - "unit/**/*.snap"
+output: dump.txt
+format: markdown
+`,
+			parentIsRepo:  true,
+			configContent: `format: xml`,
+			baseConfig:    *BuildConfig(),
+			expectedConfig: *BuildConfig(
+				WithSpecificFiles("./src/main.go", "../README.md"),
+				WithPriorityPaths("./src/main.go", "../README.md"),
+				WithSkipDirs("./src/subdir"),
+				WithGlobPatterns("!./**/*.snap"),
+				WithIgnorePaths("./src/subdir", "./**/*.snap"),
+				WithOutputFile("../dump.txt"),
+				WithFormat("xml"),
 			),
 		},
+		{
+			name: "Config files outside the repository are not loaded",
+			parentConfigContent: `
+format: markdown
+`,
+			baseConfig:     *BuildConfig(),
+			expectedConfig: *BuildConfig(),
+		},
 		{
 			name: "Invalid YAML",
 			configContent: `
@@ -116,8 +488,28 @@ include: [
 				}
 			}
 
+			if tt.parentConfigContent != "" {
+				afero.WriteFile(fs, "../README.md", []byte("# Readme"), 0644)
+				if tt.parentIsRepo {
+					fs.MkdirAll("../.git", 0755)
+				}
+				err := afero.WriteFile(fs, "../.dump_dir.yml", []byte(tt.parentConfigContent), 0644)
+				if err != nil {
+					t.Fatalf("Failed to write parent config file: %v", err)
+				}
+			}
+
+			userConfigPath := "/home/user/.config/dump_dir/config.yml"
+			if tt.userConfigContent != "" {
+				err := afero.WriteFile(fs, userConfigPath, []byte(tt.userConfigContent), 0644)
+				if err != nil {
+					t.Fatalf("Failed to write user config file: %v", err)
+				}
+			}
+
 			// Create config loader and load config
 			loader := NewConfigLoader(fs)
+			loader.UserConfigPath = userConfigPath
 			config, err := loader.LoadAndMergeConfig(tt.baseConfig)
 
 			// Check error expectations
@@ -138,3 +530,154 @@ include: [
 		})
 	}
 }
+
+func TestDecodeConfigFile(t *testing.T) {
+	tests := []struct {
+		name           string
+		configContent  string
+		expectedErrors []string
+	}{
+		{
+			name: "Valid config",
+			configContent: `include:
+  - ./src
+max_tokens: 1000
+stdout: true
+profiles:
+  docs:
+    extensions: [md]
+`,
+		},
+		{
+			name:          "Empty config",
+			configContent: "---\n",
+		},
+		{
+			name: "Unknown key with a suggestion",
+			configContent: `include:
+  - ./src
+ignroe:
+  - ./dist
+`,
+			expectedErrors: []string{`.dump_dir.yml:3:1: unknown key "ignroe" (did you mean "ignore"?)`},
+		},
+		{
+			name: "Duplicate key",
+			configContent: `ignore:
+  - ./dist
+ignore:
+  - ./vendor
+`,
+			expectedErrors: []string{`.dump_dir.yml:3:1: duplicate key "ignore", first given on line 1`},
+		},
+		{
+			name: "Wrong types",
+			configContent: `ignore: ./dist
+max_tokens: lots
+stdout: yes please
+extensions:
+  - [go]
+`,
+			expectedErrors: []string{
+				`.dump_dir.yml:1:9: ignore: expected a list, got "./dist"`,
+				`.dump_dir.yml:2:13: max_tokens: expected a number, got "lots"`,
+				`.dump_dir.yml:3:9: stdout: expected true or false, got "yes please"`,
+				`.dump_dir.yml:5:5: extensions: expected a string, got a list`,
+			},
+		},
+		{
+			name: "Invalid values",
+			configContent: `format: jsonx
+tokenizer: bogus
+max_filesize: huge
+split_size: 0KB
+max_tokens: -1
+top: -5
+split_tokens: -10
+diff: sometimes
+prompt_position: middle
+report: yaml
+`,
+			expectedErrors: []string{
+				`.dump_dir.yml:1:9: format: invalid format: jsonx (available: markdown, plain, xml)`,
+				`.dump_dir.yml:2:12: tokenizer: invalid tokenizer: bogus (available: cl100k_base, estimate, o200k_base)`,
+				`.dump_dir.yml:3:15: max_filesize: invalid max filesize: huge`,
+				`.dump_dir.yml:4:13: split_size: invalid split size: 0KB`,
+				`.dump_dir.yml:5:13: max_tokens: invalid max tokens: -1`,
+				`.dump_dir.yml:6:6: top: invalid top count: -5`,
+				`.dump_dir.yml:7:15: split_tokens: invalid split size: -10`,
+				`.dump_dir.yml:8:7: diff: invalid diff mode: sometimes (available: diff, full)`,
+				`.dump_dir.yml:9:18: prompt_position: invalid prompt position: middle (available: before, after)`,
+				`.dump_dir.yml:10:9: report: invalid report format: yaml (available: json)`,
+			},
+		},
+		{
+			name: "Invalid value in a profile",
+			configContent: `profiles:
+  big:
+    max_filesize: huge
+`,
+			expectedErrors: []string{`.dump_dir.yml:3:19: max_filesize: invalid max filesize: huge`},
+		},
+		{
+			name: "Unknown key in a profile",
+			configContent: `profiles:
+  docs:
+    extension: [md]
+`,
+			expectedErrors: []string{`.dump_dir.yml:3:5: unknown key "extension" (did you mean "extensions"?)`},
+		},
+		{
+			name: "Profile that is not a mapping",
+			configContent: `profiles:
+  docs: [md]
+`,
+			expectedErrors: []string{`.dump_dir.yml:2:9: profiles.docs: expected a mapping, got a list`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeConfigFile(".dump_dir.yml", []byte(tt.configContent))
+
+			if len(tt.expectedErrors) == 0 {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+
+			var configErrors ConfigErrors
+			if !errors.As(err, &configErrors) {
+				t.Fatalf("Expected ConfigErrors, got %v", err)
+			}
+			var messages []string
+			for _, configError := range configErrors {
+				messages = append(messages, configError.Error())
+			}
+			if diff := cmp.Diff(tt.expectedErrors, messages); diff != "" {
+				t.Errorf("Errors mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateConfigFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "project/README.md", []byte("# Readme"), 0644)
+	afero.WriteFile(fs, "project/.dump_dir.yml", []byte(`include:
+  - ./README.md
+  - ./prompts
+  - "docs/**/*.md"
+  - ./README.md:1-2
+  - ./CHANGELOG.md#Unreleased
+`), 0644)
+
+	err := ValidateConfigFile(fs, "project/.dump_dir.yml")
+
+	expected := "project/.dump_dir.yml:3:5: include: ./prompts does not exist\n" +
+		"project/.dump_dir.yml:6:5: include: ./CHANGELOG.md#Unreleased does not exist"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q, got %v", expected, err)
+	}
+}
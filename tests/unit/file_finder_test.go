@@ -2,6 +2,7 @@ package unit
 
 import (
 	. "github.com/fargusplumdoodle/dump_dir/src"
+	"io"
 	"testing"
 )
 
@@ -161,9 +162,12 @@ func TestFileFinder(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			fs := setupTestFileSystem(tt.fileSystem)
-			fileFinder := NewFileFinder(*tt.config, fs)
+			fileFinder := NewFileFinder(*tt.config, fs, io.Discard)
 
-			foundFiles := fileFinder.DiscoverFiles()
+			foundFiles, err := fileFinder.DiscoverFiles()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
 
 			assertFilesFound(t, foundFiles, tt.expectedFiles, tt.unexpectedFiles)
 		})
@@ -0,0 +1,203 @@
+package unit
+
+import (
+	"fmt"
+	. "github.com/fargusplumdoodle/dump_dir/src"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func mockGit(responses map[string]string) {
+	ExecCommand = func(name string, arg ...string) *exec.Cmd {
+		if output, ok := responses[strings.Join(arg, " ")]; ok {
+			return exec.Command("printf", output)
+		}
+		return exec.Command("printf", "")
+	}
+}
+
+func TestGitFileSelection(t *testing.T) {
+	defer ResetExecCommand()
+
+	gitResponses := map[string]string{
+		"diff --name-only -z --relative --diff-filter=d HEAD":     "src/changed.go\\0src/changed.md\\0vendor/lib/lib.go\\0",
+		"diff --name-only -z --relative --diff-filter=d --cached": "src/staged.go\\0",
+		"merge-base main HEAD": "abc123",
+		"diff --name-only -z --relative --diff-filter=d abc123": "src/branch.go\\0",
+		"ls-files -z --others --exclude-standard":               "src/new.go\\0",
+	}
+	fileSystem := map[string]string{
+		"./src/changed.go":    "package src",
+		"./src/changed.md":    "# changed",
+		"./src/staged.go":     "package src",
+		"./src/branch.go":     "package src",
+		"./src/new.go":        "package src",
+		"./src/untouched.go":  "package src",
+		"./docs/guide.md":     "# guide",
+		"./vendor/lib/lib.go": "package lib",
+	}
+
+	tests := []struct {
+		name            string
+		config          *Config
+		expectedFiles   []string
+		unexpectedFiles []string
+	}{
+		{
+			name:          "Changed files include untracked files",
+			config:        BuildConfig(WithGitChanged(true)),
+			expectedFiles: []string{"./src/changed.go", "./src/changed.md", "./src/new.go"},
+			unexpectedFiles: []string{
+				"./src/staged.go",
+				"./src/untouched.go",
+			},
+		},
+		{
+			name:            "Staged files only",
+			config:          BuildConfig(WithGitStaged(true)),
+			expectedFiles:   []string{"./src/staged.go"},
+			unexpectedFiles: []string{"./src/changed.go", "./src/new.go"},
+		},
+		{
+			name:            "Files changed since a ref",
+			config:          BuildConfig(WithGitSince("main")),
+			expectedFiles:   []string{"./src/branch.go", "./src/new.go"},
+			unexpectedFiles: []string{"./src/changed.go", "./src/untouched.go"},
+		},
+		{
+			name:            "Extension filter still applies",
+			config:          BuildConfig(WithGitChanged(true), WithExtensions("md")),
+			expectedFiles:   []string{"./src/changed.md"},
+			unexpectedFiles: []string{"./src/changed.go", "./src/new.go"},
+		},
+		{
+			name:            "Skip filter still applies",
+			config:          BuildConfig(WithGitChanged(true), WithSkipDirs("./src/new.go")),
+			expectedFiles:   []string{"./src/changed.go"},
+			unexpectedFiles: []string{"./src/new.go"},
+		},
+		{
+			name:            "Skipped directories still apply",
+			config:          BuildConfig(WithGitChanged(true), WithSkipDirs("./vendor")),
+			expectedFiles:   []string{"./src/changed.go", "./src/new.go"},
+			unexpectedFiles: []string{"./vendor/lib/lib.go"},
+		},
+		{
+			name:            "Skipped directories apply with --include-ignored",
+			config:          BuildConfig(WithGitChanged(true), WithIncludeIgnored(true), WithSkipDirs("./vendor")),
+			expectedFiles:   []string{"./src/changed.go", "./src/new.go"},
+			unexpectedFiles: []string{"./vendor/lib/lib.go"},
+		},
+		{
+			name: "Config file ignore directories still apply",
+			config: BuildConfig(
+				WithGitChanged(true),
+				WithSkipDirs("./vendor"),
+				WithIgnorePaths("./vendor"),
+			),
+			expectedFiles:   []string{"./src/changed.go"},
+			unexpectedFiles: []string{"./vendor/lib/lib.go"},
+		},
+		{
+			name:            "Paths on the command line limit the selection",
+			config:          BuildConfig(WithGitChanged(true), WithSpecificFiles("./src/changed.go")),
+			expectedFiles:   []string{"./src/changed.go"},
+			unexpectedFiles: []string{"./src/changed.md", "./src/new.go"},
+		},
+		{
+			name: "Config file includes are always added",
+			config: BuildConfig(
+				WithGitStaged(true),
+				WithDirectories("./docs"),
+				WithPriorityPaths("./docs"),
+			),
+			expectedFiles: []string{"./src/staged.go", "./docs/guide.md"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockGit(gitResponses)
+			fs := setupTestFileSystem(fileSystem)
+			fileFinder := NewFileFinder(*tt.config, fs, io.Discard)
+
+			foundFiles, err := fileFinder.DiscoverFiles()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			assertFilesFound(t, foundFiles, tt.expectedFiles, tt.unexpectedFiles)
+		})
+	}
+
+	t.Run("Deleted files are left out", func(t *testing.T) {
+		mockGit(map[string]string{
+			"diff --name-only -z --relative --diff-filter=d --cached": "src/staged.go\\0src/gone.go\\0",
+		})
+		fs := setupTestFileSystem(fileSystem)
+		fileFinder := NewFileFinder(*BuildConfig(WithGitStaged(true)), fs, io.Discard)
+
+		foundFiles, err := fileFinder.DiscoverFiles()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		assertFilesFound(t, foundFiles, []string{"./src/staged.go"}, []string{"./src/gone.go"})
+	})
+
+	t.Run("Git errors are returned", func(t *testing.T) {
+		ExecCommand = func(name string, arg ...string) *exec.Cmd {
+			return exec.Command("false")
+		}
+		fileFinder := NewFileFinder(*BuildConfig(WithGitSince("nope")), setupTestFileSystem(fileSystem), io.Discard)
+
+		_, err := fileFinder.DiscoverFiles()
+		if err == nil {
+			t.Error("Expected an error but got none")
+		}
+	})
+}
+
+func TestDiffModeBoundsGitProcesses(t *testing.T) {
+	defer ResetExecCommand()
+
+	var mu sync.Mutex
+	running, mostRunning := 0, 0
+	ExecCommand = func(name string, arg ...string) *exec.Cmd {
+		mu.Lock()
+		running++
+		mostRunning = max(mostRunning, running)
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return exec.Command("printf", "")
+	}
+
+	fileSystem := make(map[string]string)
+	var files []string
+	for i := 0; i < 40; i++ {
+		path := fmt.Sprintf("./src/file_%d.go", i)
+		fileSystem[path] = "package src\n"
+		files = append(files, path)
+	}
+	processor := NewFileProcessor(setupTestFileSystem(fileSystem), *BuildConfig(WithDiff(DiffModeOnly)), io.Discard)
+	processor.DiffBase = "abc123"
+	processor.Workers = 4
+
+	processedFiles, err := processor.ProcessFiles(files)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(processedFiles) != len(files) {
+		t.Errorf("Expected %d files, got %d", len(files), len(processedFiles))
+	}
+	if mostRunning > processor.Workers {
+		t.Errorf("Expected at most %d git commands at once, got %d", processor.Workers, mostRunning)
+	}
+}
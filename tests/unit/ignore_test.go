@@ -3,7 +3,10 @@ package unit
 import (
 	. "github.com/fargusplumdoodle/dump_dir/src"
 	"github.com/spf13/afero"
+	"io"
+	"os"
 	"os/exec"
+	"strings"
 	"testing"
 )
 
@@ -102,9 +105,146 @@ func TestIgnoreFunctionality(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			fs := setupIgnoreTestEnvironment(tt.files, tt.globalGitignore, tt.localGitignore)
-			fileFinder := NewFileFinder(*tt.config, fs)
+			fileFinder := NewFileFinder(*tt.config, fs, io.Discard)
 
-			foundFiles := fileFinder.DiscoverFiles()
+			foundFiles, err := fileFinder.DiscoverFiles()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			assertFilesFound(t, foundFiles, tt.expectedFiles, tt.unexpectedFiles)
+		})
+	}
+}
+
+func TestGitignoreSemantics(t *testing.T) {
+	tests := []struct {
+		name       string
+		fileSystem map[string]string
+		// trackedFiles are what git ls-files --cached lists
+		trackedFiles    []string
+		expectedFiles   []string
+		unexpectedFiles []string
+	}{
+		{
+			name: "Negated pattern re-includes a file",
+			fileSystem: map[string]string{
+				".gitignore": "*.log\n!keep.log\n",
+				"app.log":    "log",
+				"keep.log":   "log",
+			},
+			expectedFiles:   []string{"./keep.log"},
+			unexpectedFiles: []string{"./app.log"},
+		},
+		{
+			name: "Leading slash anchors pattern to the gitignore directory",
+			fileSystem: map[string]string{
+				".gitignore":         "/build\n",
+				"build/output.txt":   "output",
+				"src/build/util.txt": "util",
+			},
+			expectedFiles:   []string{"./src/build/util.txt"},
+			unexpectedFiles: []string{"./build/output.txt"},
+		},
+		{
+			name: "Double star matches any number of directories",
+			fileSystem: map[string]string{
+				".gitignore":         "docs/**/*.tmp\n**/cache\n",
+				"docs/a/b/draft.tmp": "draft",
+				"docs/draft.tmp":     "draft",
+				"docs/guide.md":      "guide",
+				"src/cache/data.go":  "package cache",
+				"cache/data.go":      "package cache",
+			},
+			expectedFiles: []string{"./docs/guide.md"},
+			unexpectedFiles: []string{
+				"./docs/a/b/draft.tmp",
+				"./docs/draft.tmp",
+				"./src/cache/data.go",
+				"./cache/data.go",
+			},
+		},
+		{
+			name: "Nested gitignore applies only to its own directory",
+			fileSystem: map[string]string{
+				"src/.gitignore":   "*.gen.go\n",
+				"src/model.gen.go": "package src",
+				"src/model.go":     "package src",
+				"root.gen.go":      "package main",
+			},
+			expectedFiles:   []string{"./src/model.go", "./root.gen.go"},
+			unexpectedFiles: []string{"./src/model.gen.go"},
+		},
+		{
+			name: "Nested gitignore overrides the root gitignore",
+			fileSystem: map[string]string{
+				".gitignore":     "*.txt\n",
+				"src/.gitignore": "!keep.txt\n",
+				"src/keep.txt":   "keep",
+				"other.txt":      "other",
+			},
+			expectedFiles:   []string{"./src/keep.txt"},
+			unexpectedFiles: []string{"./other.txt"},
+		},
+		{
+			name: "Files cannot be re-included inside an ignored directory",
+			fileSystem: map[string]string{
+				".gitignore":   "dist/\n!dist/keep.js\n",
+				"dist/keep.js": "keep",
+			},
+			unexpectedFiles: []string{"./dist/keep.js"},
+		},
+		{
+			name: "Directory patterns do not match files",
+			fileSystem: map[string]string{
+				".gitignore":    "logs/\n",
+				"logs":          "a file named logs",
+				"src/logs/a.go": "package logs",
+			},
+			expectedFiles:   []string{"./logs"},
+			unexpectedFiles: []string{"./src/logs/a.go"},
+		},
+		{
+			name: "Respect .git/info/exclude",
+			fileSystem: map[string]string{
+				".git/info/exclude": "secret.env\n",
+				"secret.env":        "TOKEN=abc",
+				"main.go":           "package main",
+			},
+			expectedFiles:   []string{"./main.go"},
+			unexpectedFiles: []string{"./secret.env"},
+		},
+		{
+			name: "Tracked files are kept even when an ignore pattern matches them",
+			fileSystem: map[string]string{
+				".git/HEAD":         "ref: refs/heads/main",
+				".gitignore":        "*.log\ndist/\n",
+				"fixtures/app.log":  "tracked",
+				"debug.log":         "untracked",
+				"dist/vendor.js":    "tracked",
+				"dist/generated.js": "untracked",
+			},
+			trackedFiles:  []string{".gitignore", "fixtures/app.log", "dist/vendor.js"},
+			expectedFiles: []string{"./fixtures/app.log", "./dist/vendor.js"},
+			unexpectedFiles: []string{
+				"./debug.log",
+				"./dist/generated.js",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.trackedFiles != nil {
+				mockTrackedFiles(t, tt.trackedFiles)
+			}
+			fs := setupTestFileSystem(tt.fileSystem)
+			fileFinder := NewFileFinder(*BuildConfig(WithDirectories(".")), fs, io.Discard)
+
+			foundFiles, err := fileFinder.DiscoverFiles()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
 
 			assertFilesFound(t, foundFiles, tt.expectedFiles, tt.unexpectedFiles)
 		})
@@ -113,6 +253,25 @@ func TestIgnoreFunctionality(t *testing.T) {
 
 // Helper functions
 
+// mockTrackedFiles makes git report the working directory as the
+// repository root, with files in its index
+func mockTrackedFiles(t *testing.T, files []string) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ExecCommand = func(name string, arg ...string) *exec.Cmd {
+		switch strings.Join(arg, " ") {
+		case "rev-parse --show-toplevel":
+			return exec.Command("echo", cwd)
+		case "ls-files -z --cached --full-name -- :/":
+			return exec.Command("printf", "%b", strings.Join(files, "\\0"))
+		}
+		return exec.Command("false")
+	}
+	t.Cleanup(ResetExecCommand)
+}
+
 func setupIgnoreTestEnvironment(files []string, globalGitignore, localGitignore string) afero.Fs {
 	fs := afero.NewMemMapFs()
 
@@ -0,0 +1,38 @@
+package unit
+
+import (
+	. "github.com/fargusplumdoodle/dump_dir/src"
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		contents string
+		expected string
+	}{
+		{name: "go_extension", path: "./main.go", expected: "go"},
+		{name: "typescript_extension", path: "./src/app.ts", expected: "ts"},
+		{name: "python_extension", path: "./script.py", expected: "py"},
+		{name: "yml_is_yaml", path: "./.github/workflows/ci.yml", expected: "yaml"},
+		{name: "uppercase_extension", path: "./README.MD", expected: "markdown"},
+		{name: "dockerfile_by_name", path: "./Dockerfile", expected: "dockerfile"},
+		{name: "dockerfile_with_suffix", path: "./Dockerfile.dev", expected: "dockerfile"},
+		{name: "makefile_by_name", path: "./Makefile", expected: "makefile"},
+		{name: "shebang_env", path: "./bin/tool", contents: "#!/usr/bin/env python3\n", expected: "py"},
+		{name: "shebang_env_with_flags", path: "./bin/tool", contents: "#!/usr/bin/env -S node --no-warnings\n", expected: "js"},
+		{name: "shebang_direct", path: "./scripts/build_and_install", contents: "#!/bin/bash\necho hi\n", expected: "bash"},
+		{name: "extension_beats_shebang", path: "./run.rb", contents: "#!/bin/sh\n", expected: "ruby"},
+		{name: "unknown_file", path: "./LICENSE", contents: "MIT License\n", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := DetectLanguage(tt.path, tt.contents)
+			if result != tt.expected {
+				t.Errorf("DetectLanguage(%q) = %q, want %q", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
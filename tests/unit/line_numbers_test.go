@@ -0,0 +1,69 @@
+package unit
+
+import (
+	"testing"
+
+	. "github.com/fargusplumdoodle/dump_dir/src"
+)
+
+func TestNumberLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		first    int
+		width    int
+		expected string
+	}{
+		{
+			name:     "Single line",
+			contents: "package main\n",
+			first:    1,
+			width:    1,
+			expected: "1 | package main\n",
+		},
+		{
+			name:     "Numbers are aligned in the gutter",
+			contents: "a\nb\nc\n",
+			first:    8,
+			width:    2,
+			expected: " 8 | a\n 9 | b\n10 | c\n",
+		},
+		{
+			name:     "Gutter wider than the numbers",
+			contents: "a\nb\n",
+			first:    1,
+			width:    3,
+			expected: "  1 | a\n  2 | b\n",
+		},
+		{
+			name:     "No trailing newline",
+			contents: "a\nb",
+			first:    1,
+			width:    1,
+			expected: "1 | a\n2 | b",
+		},
+		{
+			name:     "Blank lines are numbered",
+			contents: "a\n\nb\n",
+			first:    1,
+			width:    1,
+			expected: "1 | a\n2 | \n3 | b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NumberLines(tt.contents, tt.first, tt.width); got != tt.expected {
+				t.Errorf("NumberLines() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGutterWidth(t *testing.T) {
+	for last, expected := range map[int]int{0: 1, 9: 1, 10: 2, 120: 3} {
+		if got := GutterWidth(last); got != expected {
+			t.Errorf("GutterWidth(%d) = %d, want %d", last, got, expected)
+		}
+	}
+}
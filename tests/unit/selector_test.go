@@ -0,0 +1,289 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/fargusplumdoodle/dump_dir/src"
+)
+
+func TestSplitFileSelector(t *testing.T) {
+	tests := []struct {
+		arg              string
+		expectedPath     string
+		expectedSelector FileSelector
+		expectedOk       bool
+		expectError      bool
+	}{
+		{arg: "main.go", expectedPath: "main.go"},
+		{arg: "main.go:40-120", expectedPath: "main.go", expectedSelector: FileSelector{Start: 40, End: 120}, expectedOk: true},
+		{arg: "main.go:7", expectedPath: "main.go", expectedSelector: FileSelector{Start: 7, End: 7}, expectedOk: true},
+		{arg: "src/run.go#performDumpDir", expectedPath: "src/run.go", expectedSelector: FileSelector{Symbol: "performDumpDir"}, expectedOk: true},
+		{arg: "file_parser.go#FileProcessor.processFile", expectedPath: "file_parser.go", expectedSelector: FileSelector{Symbol: "FileProcessor.processFile"}, expectedOk: true},
+		{arg: "notes#1.md", expectedPath: "notes#1.md"},
+		{arg: "main.go:120-40", expectError: true},
+		{arg: "main.go:0", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			path, selector, ok, err := SplitFileSelector(tt.arg)
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected an error, got %q %v", path, selector)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if path != tt.expectedPath || selector != tt.expectedSelector || ok != tt.expectedOk {
+				t.Errorf("SplitFileSelector() = %q, %v, %v, want %q, %v, %v", path, selector, ok, tt.expectedPath, tt.expectedSelector, tt.expectedOk)
+			}
+		})
+	}
+}
+
+const goSource = `package main
+
+import "fmt"
+
+// Greeter says hello
+type Greeter struct {
+	Name string
+}
+
+// Greet prints the greeting
+func (g *Greeter) Greet() {
+	fmt.Println("hello", g.Name)
+}
+
+func main() {
+	g := &Greeter{Name: "world"}
+	g.Greet()
+}
+
+const (
+	First  = 1
+	Second = 2
+)
+`
+
+const pythonSource = `import os
+
+
+class Loader:
+    def __init__(self, path):
+        self.path = path
+
+    @property
+    def name(self):
+        return os.path.basename(self.path)
+
+
+def load(path):
+    loader = Loader(path)
+
+    return loader.name
+`
+
+const javascriptSource = `const limit = 10;
+
+// Fetches the user
+export async function fetchUser(id) {
+  const response = await fetch(` + "`/users/${id}`" + `);
+  return response.json();
+}
+
+class Cache {
+  get(key) {
+    return this.values[key];
+  }
+}
+`
+
+func TestSelectRegions(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		contents  string
+		selectors []FileSelector
+		expected  []Region
+	}{
+		{
+			name:      "Line range",
+			path:      "main.go",
+			contents:  goSource,
+			selectors: []FileSelector{{Start: 3, End: 4}},
+			expected:  []Region{{Start: 3, End: 4}},
+		},
+		{
+			name:      "Line range past the end is cut short",
+			path:      "main.go",
+			contents:  goSource,
+			selectors: []FileSelector{{Start: 20, End: 400}},
+			expected:  []Region{{Start: 20, End: 23}},
+		},
+		{
+			name:      "Go function",
+			path:      "main.go",
+			contents:  goSource,
+			selectors: []FileSelector{{Symbol: "main"}},
+			expected:  []Region{{Start: 15, End: 18, Symbol: "main"}},
+		},
+		{
+			name:      "Go method with its doc comment",
+			path:      "main.go",
+			contents:  goSource,
+			selectors: []FileSelector{{Symbol: "Greeter.Greet"}},
+			expected:  []Region{{Start: 10, End: 13, Symbol: "Greeter.Greet"}},
+		},
+		{
+			name:      "Go method by its name alone",
+			path:      "main.go",
+			contents:  goSource,
+			selectors: []FileSelector{{Symbol: "Greet"}},
+			expected:  []Region{{Start: 10, End: 13, Symbol: "Greet"}},
+		},
+		{
+			name:      "Go type and constant, in file order",
+			path:      "main.go",
+			contents:  goSource,
+			selectors: []FileSelector{{Symbol: "Second"}, {Symbol: "Greeter"}},
+			expected:  []Region{{Start: 5, End: 8, Symbol: "Greeter"}, {Start: 22, End: 22, Symbol: "Second"}},
+		},
+		{
+			name:      "Overlapping ranges are merged",
+			path:      "main.go",
+			contents:  goSource,
+			selectors: []FileSelector{{Start: 5, End: 10}, {Start: 1, End: 8}},
+			expected:  []Region{{Start: 1, End: 10}},
+		},
+		{
+			name:      "Adjacent ranges are merged",
+			path:      "main.go",
+			contents:  goSource,
+			selectors: []FileSelector{{Start: 1, End: 4}, {Start: 5, End: 6}, {Start: 8, End: 9}},
+			expected:  []Region{{Start: 1, End: 6}, {Start: 8, End: 9}},
+		},
+		{
+			name:      "A range inside a symbol is merged into it",
+			path:      "main.go",
+			contents:  goSource,
+			selectors: []FileSelector{{Start: 16, End: 17}, {Symbol: "main"}},
+			expected:  []Region{{Start: 15, End: 18, Symbol: "main"}},
+		},
+		{
+			name:      "Symbols joined by a range keep both names",
+			path:      "main.go",
+			contents:  goSource,
+			selectors: []FileSelector{{Symbol: "Greet"}, {Start: 8, End: 9}, {Symbol: "Greeter"}},
+			expected:  []Region{{Start: 5, End: 13, Symbol: "Greeter and Greet"}},
+		},
+		{
+			name:      "Python function ends where the indentation does",
+			path:      "loader.py",
+			contents:  pythonSource,
+			selectors: []FileSelector{{Symbol: "load"}},
+			expected:  []Region{{Start: 13, End: 16, Symbol: "load"}},
+		},
+		{
+			name:      "Python method with its decorator",
+			path:      "loader.py",
+			contents:  pythonSource,
+			selectors: []FileSelector{{Symbol: "Loader.name"}},
+			expected:  []Region{{Start: 8, End: 10, Symbol: "Loader.name"}},
+		},
+		{
+			name:      "JavaScript function ends where its braces close",
+			path:      "api.js",
+			contents:  javascriptSource,
+			selectors: []FileSelector{{Symbol: "fetchUser"}},
+			expected:  []Region{{Start: 3, End: 7, Symbol: "fetchUser"}},
+		},
+		{
+			name:      "JavaScript method",
+			path:      "api.js",
+			contents:  javascriptSource,
+			selectors: []FileSelector{{Symbol: "Cache.get"}},
+			expected:  []Region{{Start: 10, End: 12, Symbol: "Cache.get"}},
+		},
+		{
+			name:      "JavaScript constant",
+			path:      "api.js",
+			contents:  javascriptSource,
+			selectors: []FileSelector{{Symbol: "limit"}},
+			expected:  []Region{{Start: 1, End: 1, Symbol: "limit"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SelectRegions(tt.path, tt.contents, tt.selectors)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != len(tt.expected) {
+				t.Fatalf("SelectRegions() = %v, want %v", got, tt.expected)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("SelectRegions()[%d] = %v, want %v", i, got[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSelectRegionsErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		selectors []FileSelector
+		expected  string
+	}{
+		{
+			name:      "Range after the end of the file",
+			selectors: []FileSelector{{Start: 100, End: 120}},
+			expected:  "invalid selector main.go:100-120: starts after the end of the file (23 lines)",
+		},
+		{
+			name:      "Missing symbol",
+			selectors: []FileSelector{{Symbol: "missing"}},
+			expected:  "invalid selector main.go#missing: symbol not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := SelectRegions("main.go", goSource, tt.selectors)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("expected an error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestExtractRegions(t *testing.T) {
+	contents := "one\ntwo\nthree\nfour\nfive\n"
+	regions := []Region{{Start: 1, End: 2}, {Start: 4, End: 4}}
+
+	if got, expected := ExtractRegions(contents, regions, false), "one\ntwo\n...\nfour\n"; got != expected {
+		t.Errorf("ExtractRegions() = %q, want %q", got, expected)
+	}
+	if got, expected := ExtractRegions(contents, regions, true), "1 | one\n2 | two\n...\n4 | four\n"; got != expected {
+		t.Errorf("ExtractRegions() with line numbers = %q, want %q", got, expected)
+	}
+	if got, expected := DescribeRegions(regions, 5), "lines 1-2; line 4 of 5"; got != expected {
+		t.Errorf("DescribeRegions() = %q, want %q", got, expected)
+	}
+}
+
+func TestExtractRegionsSharesOneGutter(t *testing.T) {
+	contents := strings.Repeat("line\n", 12)
+	regions := []Region{{Start: 1, End: 2}, {Start: 9, End: 10}}
+
+	expected := " 1 | line\n 2 | line\n...\n 9 | line\n10 | line\n"
+	if got := ExtractRegions(contents, regions, true); got != expected {
+		t.Errorf("ExtractRegions() = %q, want %q", got, expected)
+	}
+}
@@ -0,0 +1,227 @@
+package unit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	"strings"
+	"testing"
+
+	. "github.com/fargusplumdoodle/dump_dir/src"
+)
+
+// testVocabulary has every single byte, ranked by its value, followed by
+// the given merges
+func testVocabulary(merges ...string) string {
+	var vocabulary strings.Builder
+	for b := 0; b < 256; b++ {
+		fmt.Fprintf(&vocabulary, "%s %d\n", base64.StdEncoding.EncodeToString([]byte{byte(b)}), b)
+	}
+	for i, merge := range merges {
+		fmt.Fprintf(&vocabulary, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(merge)), 256+i)
+	}
+	return vocabulary.String()
+}
+
+func TestBPESplit(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding BPEEncoding
+		text     string
+		expected []string
+	}{
+		{
+			name:     "words take their leading space",
+			encoding: Cl100kBase,
+			text:     "Hello world",
+			expected: []string{"Hello", " world"},
+		},
+		{
+			name:     "space runs leave the last space to the next word",
+			encoding: Cl100kBase,
+			text:     "a   b",
+			expected: []string{"a", "  ", " b"},
+		},
+		{
+			name:     "line breaks",
+			encoding: Cl100kBase,
+			text:     "foo\n\n  bar",
+			expected: []string{"foo", "\n\n", " ", " bar"},
+		},
+		{
+			name:     "numbers in groups of three",
+			encoding: Cl100kBase,
+			text:     "x 12345",
+			expected: []string{"x", " ", "123", "45"},
+		},
+		{
+			name:     "contractions",
+			encoding: Cl100kBase,
+			text:     "don't",
+			expected: []string{"don", "'t"},
+		},
+		{
+			name:     "punctuation and trailing spaces",
+			encoding: Cl100kBase,
+			text:     "f(x);  ",
+			expected: []string{"f", "(x", ");", "  "},
+		},
+		{
+			name:     "o200k splits camel case",
+			encoding: O200kBase,
+			text:     "parseHTTPRequest",
+			expected: []string{"parse", "HTTPRequest"},
+		},
+		{
+			name:     "o200k keeps contractions on the word",
+			encoding: O200kBase,
+			text:     "don't stop",
+			expected: []string{"don't", " stop"},
+		},
+		{
+			name:     "o200k joins slashes to punctuation",
+			encoding: O200kBase,
+			text:     "a.b/c",
+			expected: []string{"a", ".b", "/c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenizer, err := NewBPETokenizer(tt.encoding.Pattern, strings.NewReader(testVocabulary()))
+			if err != nil {
+				t.Fatalf("NewBPETokenizer returned an error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, tokenizer.Split(tt.text)); diff != "" {
+				t.Errorf("Split mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBPEEncode(t *testing.T) {
+	tokenizer, err := NewBPETokenizer(Cl100kBase.Pattern, strings.NewReader(testVocabulary("ll", "He", "Hell", " w")))
+	if err != nil {
+		t.Fatalf("NewBPETokenizer returned an error: %v", err)
+	}
+
+	// "ll" has the lowest rank so it is merged first, then "He", then "Hell"
+	expected := []int{258, 'o', 259, 'o', 'r', 'l', 'd'}
+	if diff := cmp.Diff(expected, tokenizer.Encode("Hello world")); diff != "" {
+		t.Errorf("Encode mismatch (-want +got):\n%s", diff)
+	}
+	if count := tokenizer.CountTokens("Hello world"); count != len(expected) {
+		t.Errorf("Expected %d tokens, got %d", len(expected), count)
+	}
+}
+
+func TestBPEVocabularyErrors(t *testing.T) {
+	_, err := NewBPETokenizer(Cl100kBase.Pattern, strings.NewReader("aGk=\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("Expected an error for line 1, got: %v", err)
+	}
+}
+
+// loadRealTokenizer loads a published vocabulary from the binary
+func loadRealTokenizer(t *testing.T, encoding BPEEncoding) *BPETokenizer {
+	t.Helper()
+	tokenizer, err := LoadBPETokenizer(encoding)
+	if err != nil {
+		t.Fatalf("Loading %s: %v", encoding.Name, err)
+	}
+	return tokenizer
+}
+
+func TestBPEGoldenEncodings(t *testing.T) {
+	// Token ids from tiktoken for the same strings
+	tests := []struct {
+		encoding BPEEncoding
+		text     string
+		expected []int
+	}{
+		{Cl100kBase, "hello world", []int{15339, 1917}},
+		{Cl100kBase, "rer", []int{38149}},
+		{Cl100kBase, "'rer", []int{2351, 81}},
+		{Cl100kBase, "today\n ", []int{31213, 198, 220}},
+		{Cl100kBase, "today\n \n", []int{31213, 27907}},
+		{Cl100kBase, "today\n  \n", []int{31213, 14211}},
+		{O200kBase, "hello world", []int{24912, 2375}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.encoding.Name+"/"+tt.text, func(t *testing.T) {
+			tokenizer := loadRealTokenizer(t, tt.encoding)
+
+			if diff := cmp.Diff(tt.expected, tokenizer.Encode(tt.text)); diff != "" {
+				t.Errorf("Encode(%q) mismatch (-want +got):\n%s", tt.text, diff)
+			}
+			if count := tokenizer.CountTokens(tt.text); count != len(tt.expected) {
+				t.Errorf("CountTokens(%q) = %d, want %d", tt.text, count, len(tt.expected))
+			}
+		})
+	}
+}
+
+// gzipped compresses a vocabulary the way it is embedded
+func gzipped(t *testing.T, vocabulary string) *bytes.Buffer {
+	t.Helper()
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write([]byte(vocabulary)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &compressed
+}
+
+func TestBPEVocabularyChecksum(t *testing.T) {
+	vocabulary := testVocabulary("ll")
+	sum := sha256.Sum256([]byte(vocabulary))
+
+	t.Run("matching_checksum_loads", func(t *testing.T) {
+		encoding := BPEEncoding{Name: "checksum_test", SHA256: hex.EncodeToString(sum[:])}
+		decompressed, err := DecompressVocabulary(encoding, gzipped(t, vocabulary))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if string(decompressed) != vocabulary {
+			t.Errorf("Expected the vocabulary back unchanged")
+		}
+	})
+
+	t.Run("mismatched_checksum_is_refused", func(t *testing.T) {
+		encoding := BPEEncoding{Name: "checksum_test", SHA256: strings.Repeat("0", 64)}
+		_, err := DecompressVocabulary(encoding, gzipped(t, vocabulary))
+		if err == nil || !strings.Contains(err.Error(), "sha256") {
+			t.Errorf("Expected a checksum error, got: %v", err)
+		}
+	})
+
+	t.Run("embedded_vocabularies_match", func(t *testing.T) {
+		for _, encoding := range []BPEEncoding{Cl100kBase, O200kBase} {
+			if _, err := LoadBPETokenizer(encoding); err != nil {
+				t.Errorf("Loading %s: %v", encoding.Name, err)
+			}
+		}
+	})
+}
+
+func TestNewTokenizer(t *testing.T) {
+	tokenizer, err := NewTokenizer("")
+	if err != nil {
+		t.Fatalf("Expected the default tokenizer, got error: %v", err)
+	}
+	if _, ok := tokenizer.(*TokenEstimator); !ok {
+		t.Errorf("Expected the estimator by default, got %T", tokenizer)
+	}
+
+	if _, err := NewTokenizer("gpt2"); err != (ErrInvalidTokenizer{Value: "gpt2"}) {
+		t.Errorf("Expected ErrInvalidTokenizer, got: %v", err)
+	}
+}
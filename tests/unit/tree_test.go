@@ -0,0 +1,153 @@
+package unit
+
+import (
+	"testing"
+
+	. "github.com/fargusplumdoodle/dump_dir/src"
+)
+
+func TestRenderTree(t *testing.T) {
+	tests := []struct {
+		name     string
+		entries  []TreeEntry
+		expected string
+	}{
+		{
+			name:     "No files",
+			entries:  nil,
+			expected: ".\n",
+		},
+		{
+			name: "Nested directories",
+			entries: []TreeEntry{
+				{Path: "./src/util.go"},
+				{Path: "./README.md"},
+				{Path: "./src/main.go"},
+				{Path: "./src/api/handler.go"},
+			},
+			expected: `.
+├── README.md
+└── src/
+    ├── api/
+    │   └── handler.go
+    ├── main.go
+    └── util.go
+`,
+		},
+		{
+			name: "Paths outside the working directory",
+			entries: []TreeEntry{
+				{Path: "../README.md"},
+				{Path: "./main.go"},
+			},
+			expected: `.
+├── main.go
+└── ../
+    └── README.md
+`,
+		},
+		{
+			name: "Notes on left out entries",
+			entries: []TreeEntry{
+				{Path: "./main.go"},
+				{Path: "./logo.png", Note: "binary, not shown"},
+				{Path: "./dist", IsDir: true, Note: "skipped"},
+				{Path: "./debug.log", Note: "ignored"},
+			},
+			expected: `.
+├── debug.log (ignored)
+├── dist/ (skipped)
+├── logo.png (binary, not shown)
+└── main.go
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RenderTree(tt.entries); got != tt.expected {
+				t.Errorf("RenderTree() =\n%s\nwant:\n%s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBuildTree(t *testing.T) {
+	stats := Stats{
+		ProcessedFiles: []FileInfo{
+			{Path: "./main.go", Status: StatusParsed, Size: 2048, Lines: 80, Tokens: 512},
+			{Path: "./one.txt", Status: StatusParsed, Size: 4, Lines: 1, Tokens: 1},
+			{Path: "./logo.png", Status: StatusSkippedBinary, Size: 3 * 1024 * 1024},
+			{Path: "./data.csv", Status: StatusSkippedTooLarge, Size: 900 * 1024},
+			{Path: "./run.go", Status: StatusParsed, Size: 9000, Lines: 3, Tokens: 4, Region: "lines 2-4 of 300", Contents: "a\nb\nc\n"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		sizes    bool
+		expected []TreeEntry
+	}{
+		{
+			name:  "Notes on files without contents",
+			sizes: false,
+			expected: []TreeEntry{
+				{Path: "./main.go"},
+				{Path: "./one.txt"},
+				{Path: "./logo.png", Note: "binary, not shown"},
+				{Path: "./data.csv", Note: "too large, not shown"},
+				{Path: "./run.go"},
+			},
+		},
+		{
+			name:  "Sizes of every file",
+			sizes: true,
+			expected: []TreeEntry{
+				{Path: "./main.go", Note: "80 lines, 512 tokens, 2.0 KB"},
+				{Path: "./one.txt", Note: "1 line, 1 token, 4 B"},
+				{Path: "./logo.png", Note: "binary, 3.0 MB"},
+				{Path: "./data.csv", Note: "too large, 900.0 KB"},
+				{Path: "./run.go", Note: "lines 2-4 of 300: 3 lines, 4 tokens, 6 B"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildTree(stats, nil, tt.sizes)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("BuildTree() = %v, want %v", got, tt.expected)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("BuildTree()[%d] = %v, want %v", i, got[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBuildTreeDecisions(t *testing.T) {
+	stats := Stats{ProcessedFiles: []FileInfo{{Path: "./src/main.go", Status: StatusParsed}}}
+	decisions := []Decision{
+		{Path: "./src/main.go", Included: true, Reason: "no filter excludes it"},
+		{Path: "./src/debug.log", Reason: "ignored by .gitignore:1 (*.log)"},
+		{Path: "./src/vendor", IsDir: true, Reason: "--skip ./src/vendor"},
+		{Path: "./docs/guide.md", Reason: "outside the paths on the command line", OutOfScope: true},
+	}
+
+	expected := []TreeEntry{
+		{Path: "./src/main.go"},
+		{Path: "./src/debug.log", Note: "ignored"},
+		{Path: "./src/vendor", IsDir: true, Note: "skipped"},
+	}
+	got := BuildTree(stats, decisions, false)
+	if len(got) != len(expected) {
+		t.Fatalf("BuildTree() = %v, want %v", got, expected)
+	}
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Errorf("BuildTree()[%d] = %v, want %v", i, got[i], expected[i])
+		}
+	}
+}